@@ -0,0 +1,20 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+// gogo-vet reports why the exported functions of gogo files aren't gadgets. It runs on its own, like
+// `gogo-vet ./...`, or as a tool of go vet, like `go vet -vettool=$(which gogo-vet) -tags=gogo ./...`.
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/2bit-software/gogo/pkg/gadgets"
+)
+
+func main() {
+	singlechecker.Main(gadgets.Analyzer)
+}
@@ -96,6 +96,16 @@ func NewApp() *cli.App {
 				Usage:   "Verbose output",
 				EnvVars: []string{"GOGO_VERBOSE"},
 			},
+			&cli.BoolFlag{
+				Name:    "layered",
+				Usage:   "Use the gogo files of every parent directory up to the workspace boundary, not only the nearest",
+				EnvVars: []string{"GOGO_LAYERED"},
+			},
+			&cli.BoolFlag{
+				Name:    "all",
+				Usage:   "List the hidden functions too",
+				EnvVars: []string{"GOGO_ALL"},
+			},
 			// select the exact folder to use for gogo files
 			&cli.StringFlag{
 				Name:    "source",
@@ -109,6 +119,7 @@ func NewApp() *cli.App {
 			GadgetCommand(),
 			BuildCommand(),
 			InitCommand(),
+			LintCommand(),
 		},
 	}
 
@@ -8,6 +8,7 @@ package cmds
 import (
 	"fmt"
 	"os"
+	"path"
 
 	"github.com/urfave/cli/v2"
 
@@ -19,10 +20,12 @@ import (
 func BuildOptions(ctx *cli.Context) (gadgets.RunOpts, error) {
 	runOpts := gadgets.RunOpts{
 		Verbose:          ctx.Bool("verbose"),
-		GlobalSourceDir:  getEnvOrDefault("GOGO_GLOBAL_SOURCE_DIR", ""),
+		GlobalSourceDir:  getEnvOrDefault("GOGO_GLOBAL_SOURCE_DIR", defaultGlobalSourceDir()),
 		GlobalBinDir:     getEnvOrDefault("GOGO_GLOBAL_BIN_DIR", ""),
 		BuildLocalCache:  ctx.Bool("build-local"),
 		BuildGlobalCache: ctx.Bool("global"),
+		Layered:          ctx.Bool("layered"),
+		ShowHidden:       ctx.Bool("all"),
 		BuildOpts: gadgets.BuildOpts{
 			KeepArtifacts:  ctx.Bool("keep-artifacts"),
 			DisableCache:   ctx.Bool("disable-cache"),
@@ -44,6 +47,14 @@ func BuildOptions(ctx *cli.Context) (gadgets.RunOpts, error) {
 
 	runOpts.OriginalWorkingDir = cwd
 
+	// flags on the build command take precedence over the environment
+	if dir := ctx.String("global-source-dir"); dir != "" {
+		runOpts.GlobalSourceDir = dir
+	}
+	if dir := ctx.String("global-bin-dir"); dir != "" {
+		runOpts.GlobalBinDir = dir
+	}
+
 	return runOpts, nil
 }
 
@@ -54,3 +65,12 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// defaultGlobalSourceDir returns the global gogo folder, which is the same folder `gogo init --global` creates
+func defaultGlobalSourceDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return path.Join(home, ".gogo")
+}
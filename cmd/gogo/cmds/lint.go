@@ -0,0 +1,48 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package cmds
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/2bit-software/gogo/pkg/gadgets"
+)
+
+// lintAction prints the problems with the gogo files, and fails if there are any
+func lintAction(ctx *cli.Context) error {
+	opts, err := BuildOptions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to build options: %w", err)
+	}
+	count, err := gadgets.Lint(opts, os.Stdout)
+	if err != nil {
+		return fmt.Errorf("failed to lint: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("found %d problems", count)
+	}
+	return nil
+}
+
+// LintCommand creates the lint command, which explains why exported functions aren't gadgets.
+func LintCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "lint",
+		Usage: "Report why functions are not gadgets",
+		Description: `Report the exported functions of the gogo files that can't be run as gadgets, and why,
+like a pointer argument, or a context that isn't the first parameter. Invalid uses of the
+context are reported too. Each problem is printed with the file and line it's at.
+
+The same checks can be run by go vet and editors with the gogo-vet command:
+  go vet -vettool=$(which gogo-vet) -tags=gogo ./...`,
+		Action: lintAction,
+	}
+}
@@ -52,7 +52,15 @@ func executeGoGoCommand(ctx *cli.Context) error {
 
 	args := ctx.Args().Slice()
 
+	// like mage, run the default target when one is set, otherwise list the functions
 	if len(args) == 0 {
+		ran, err := gadgets.RunDefault(opts)
+		if err != nil {
+			return fmt.Errorf("execution failed: %w", err)
+		}
+		if ran {
+			return nil
+		}
 		return handleFunctionListDisplay(ctx, opts)
 	}
 
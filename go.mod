@@ -3,14 +3,16 @@ module github.com/2bit-software/gogo
 go 1.23.2
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/bradleyjkemp/cupaloy v2.3.0+incompatible
 	github.com/fatih/color v1.18.0
 	github.com/muesli/reflow v0.3.0
 	github.com/mvdan/sh v2.6.4+incompatible
 	github.com/stretchr/testify v1.9.0
 	github.com/urfave/cli/v2 v2.27.5
-	golang.org/x/mod v0.9.0
+	golang.org/x/mod v0.22.0
 	golang.org/x/term v0.27.0
+	golang.org/x/tools v0.28.0
 )
 
 require (
@@ -26,7 +28,7 @@ require (
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/xrash/smetrics v0.0.0-20240521201337-686a1a2994c1 // indirect
 	golang.org/x/crypto v0.31.0 // indirect
-	golang.org/x/sync v0.8.0 // indirect
+	golang.org/x/sync v0.10.0 // indirect
 	golang.org/x/sys v0.28.0 // indirect
 	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
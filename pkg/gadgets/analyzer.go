@@ -0,0 +1,87 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"errors"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+
+	"github.com/2bit-software/gogo/pkg/tags"
+)
+
+// Analyzer reports the same problems as `gogo lint`, for go vet and editors. It only looks at the packages of
+// gadgets, which are the ones in a gogo folder, or with a file tagged for gogo or mage. The gogo-vet command
+// runs it, on its own or with `go vet -vettool=$(which gogo-vet) -tags=gogo`.
+var Analyzer = &analysis.Analyzer{
+	Name: "gogo",
+	Doc: `report the exported functions of gogo files that aren't gadgets, and why
+
+An exported function is skipped when gogo can't run it, like when it has a pointer argument,
+the context isn't its first parameter, or it returns more than a value and an error.
+Invalid uses of the context, which are ignored, are reported too, and so are the exported
+methods of options structs and argument types, which aren't command groups.`,
+	Run: runAnalyzer,
+}
+
+func runAnalyzer(pass *analysis.Pass) (any, error) {
+	if !isGadgetPackage(pass.Fset, pass.Files) {
+		return nil, nil
+	}
+	checked := &checkedPackage{fset: pass.Fset, pkg: pass.Pkg, info: pass.TypesInfo}
+	for _, file := range pass.Files {
+		// a main file left behind by an earlier build isn't one of the gadgets
+		if filepath.Base(pass.Fset.File(file.Pos()).Name()) == MAIN_FILENAME {
+			continue
+		}
+		checked.files = append(checked.files, file)
+	}
+	if len(checked.files) == 0 {
+		return nil, nil
+	}
+	_, diags, err := analyzePackage(checked)
+	for _, diag := range diags {
+		pass.Report(analysis.Diagnostic{Pos: diag.Pos, Category: diag.Category, Message: diag.describe()})
+	}
+	// the conflicts are already diagnostics, and the other errors are about the whole package
+	var conflict *conflictError
+	if err != nil && !errors.As(err, &conflict) {
+		pass.Report(analysis.Diagnostic{Pos: checked.files[0].Package, Category: CATEGORY_CONFLICT, Message: err.Error()})
+	}
+	return nil, nil
+}
+
+// isGadgetPackage checks if the files are gadgets, because they're in a gogo folder, or one of its command
+// groups, or because one of them is tagged for gogo or mage
+func isGadgetPackage(fset *token.FileSet, files []*ast.File) bool {
+	for _, file := range files {
+		dir := filepath.Dir(fset.File(file.Pos()).Name())
+		for _, folder := range strings.Split(filepath.ToSlash(dir), "/") {
+			if slices.Contains(gogoFolders, folder) {
+				return true
+			}
+		}
+		// the build constraints are the comments before the package clause
+		for _, group := range file.Comments {
+			if group.Pos() > file.Package {
+				break
+			}
+			for _, comment := range group.List {
+				if tags.HasBuildTag(comment.Text, gogoTags) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
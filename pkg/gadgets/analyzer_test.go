@@ -0,0 +1,75 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/analysis"
+)
+
+func TestAnalyzer(t *testing.T) {
+	const src = "package tools\n\nfunc Deploy(env string, cfg *Config) {}\n\ntype Config struct{ Env string }\n"
+	tests := []struct {
+		name     string
+		filename string
+		src      string
+		expected []string
+	}{
+		{
+			name:     "gogo folder",
+			filename: "/repo/.gogo/build.go",
+			src:      src,
+			expected: []string{"/repo/.gogo/build.go:3:25: Deploy: parameter 2 has unsupported type *Config"},
+		},
+		{
+			name:     "command group",
+			filename: "/repo/magefiles/docker/build.go",
+			src:      src,
+			expected: []string{"/repo/magefiles/docker/build.go:3:25: Deploy: parameter 2 has unsupported type *Config"},
+		},
+		{
+			name:     "tagged file",
+			filename: "/repo/tools/build.go",
+			src:      "//go:build mage\n\n" + src,
+			expected: []string{"/repo/tools/build.go:5:25: Deploy: parameter 2 has unsupported type *Config"},
+		},
+		{
+			name:     "other package",
+			filename: "/repo/tools/build.go",
+			src:      src,
+		},
+		{
+			name:     "main file",
+			filename: "/repo/.gogo/" + MAIN_FILENAME,
+			src:      src,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checked := checkSources([]string{tt.filename}, []string{tt.src})
+			var got []string
+			pass := &analysis.Pass{
+				Analyzer:  Analyzer,
+				Fset:      checked.fset,
+				Files:     checked.files,
+				Pkg:       checked.pkg,
+				TypesInfo: checked.info,
+				Report: func(diag analysis.Diagnostic) {
+					assert.Equal(t, CATEGORY_SIGNATURE, diag.Category)
+					got = append(got, checked.fset.Position(diag.Pos).String()+": "+diag.Message)
+				},
+			}
+			_, err := Analyzer.Run(pass)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
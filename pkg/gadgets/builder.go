@@ -8,14 +8,16 @@
 package gadgets
 
 import (
+	"encoding/json"
 	"fmt"
 	"go/format"
 	"hash/fnv"
 	"io"
 	"log"
 	"os"
-	"path"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -23,21 +25,48 @@ import (
 )
 
 type renderData struct {
-	GoGoImportPath string // the import path of the package
-	UseGoGoContext bool   // if any of the commands use the gogo context, then include the context in the main file
-	ImportSlices   bool   // whether to include the slices package or not
+	GoGoImportPath string     // the import path of the package
+	UseGoGoContext bool       // if any of the commands use the gogo context, then include the context in the main file
+	ImportSlices   bool       // whether to include the slices package or not
+	ImportTime     bool       // whether to include the time package, for time.Duration arguments
+	OutputFlag     bool       // whether to include the global --output flag, for the commands that return a value
+	Imports        []GoImport // the packages of the command groups
+	GroupAliases   []GroupAlias
 	RootCmd        GoCmd
 	SubCommands    []GoCmd
 }
 
+// GroupAlias is an alias of a function in a command group. Like with gogo, the alias runs the function without
+// its group, so the generated main file replaces it with the group and the function's name.
+type GroupAlias struct {
+	Alias string   // the alias, as it's given to the binary
+	Args  []string // the arguments that run the function, like docker and Build
+}
+
+// GoImport is a package imported by the generated main file
+type GoImport struct {
+	Alias string // the name the package is imported as
+	Path  string // the import path of the package
+}
+
 type GoCmd struct {
 	Name           string // Name of the command
 	Short          string // Short Description of the command. Comes from the comment, unless overridden.
 	Long           string // Long Description of the command. This comes from the comment, if it exists.
 	Example        string // An example of using this command
 	GoFlags        []GoFlag
-	ErrorReturn    bool // If true, the command returns an error
-	UseGoGoContext bool // If true, the command uses the gogo context
+	ErrorReturn    bool     // If true, the command returns an error
+	ValueReturn    bool     // If true, the command returns a value, which is written in the format of the --output flag
+	Stream         string   // how the returned value streams its elements, which are written as they arrive, if it does
+	UseGoGoContext bool     // If true, the command uses the gogo context
+	Package        string   // the alias of the package the function is in, when it is not in the main package
+	Receiver       string   // the mage namespace or task struct the function is a method of, which it is called on a new value of
+	Options        string   // the struct type the function takes its options as, when the flags are its fields
+	Variadic       *GoFlag  // the variadic parameter, which is passed the positional arguments left after the flags
+	Aliases        []string // other names the command can be run with, like its kebab-case name
+	Hidden         bool     // If true, the command isn't shown in the help, but can still be run
+	Commands       []GoCmd  // when set, this is a command group, and these are the commands within it
+	Function       bool     // if true, the command runs a function, which a group with the function's name also does
 }
 
 type GoFlag struct {
@@ -49,6 +78,11 @@ type GoFlag struct {
 	Help             string // help text for the flag
 	AllowedValues    []any  // if provided, only these values are allowed, and are auto-completed in the shell
 	RestrictedValues []any  // if provided, prohibits this flag from being set to these values. Panics if detected.
+	TextType         bool   // if true, the type implements encoding.TextUnmarshaler, and is parsed with it
+	Underlying       string // the scalar type of an enum type, which the flag is parsed as
+	Field            string // the field of the options struct the flag sets, when the function takes one
+	Env              string // the environment variable the flag is read from, overriding the default name
+	Required         bool   // if true, the flag must be given, or set by position or its environment variable
 }
 
 type RunOpts struct {
@@ -58,6 +92,8 @@ type RunOpts struct {
 	GlobalBinDir     string `json:"GOGO_GLOBAL_BIN_DIR"`    // the output location for global binaries
 	BuildLocalCache  bool   `json:"GOGO_BUILD_LOCAL"`       // When true, builds the local cache and exits
 	BuildGlobalCache bool   `json:"GOGO_BUILD_GLOBAL"`      // When true, builds the global cache and exits
+	Layered          bool   `json:"GOGO_LAYERED"`           // When true, uses every local layer up to the workspace boundary, instead of only the nearest
+	ShowHidden       bool   `json:"GOGO_ALL"`               // When true, the hidden functions are listed too
 	ScreenWidth      int    // the width of the screen, if we know
 	logger           *log.Logger
 }
@@ -80,9 +116,10 @@ type BuildOpts struct {
 	Optimize       bool   `json:"GOGO_OPTIMIZE"`       // should the functions be compiled with optimization flags during this run
 	BinaryFilepath string `json:"GOGO_OUTPUT"`         // the output location of the binary. If this is provided, then we don't calculate the filename or the location
 	// The below properties are calculated by the build process
-	SourceDir          string `json:"GOGO_SOURCE_DIR"` // the location of the directory where we are currently building the source
-	OutputDir          string // the output location of the binaries
-	OriginalWorkingDir string // the original working directory
+	SourceDir          string   `json:"GOGO_SOURCE_DIR"` // the location of the directory where we are currently building the source
+	SourceFiles        []string // when set, only these files in the SourceDir are built, instead of the entire directory
+	OutputDir          string   // the output location of the binaries
+	OriginalWorkingDir string   // the original working directory
 }
 
 func defaultFuncMap() template.FuncMap {
@@ -114,6 +151,16 @@ func defaultFuncMap() template.FuncMap {
 		"Subtract": func(a, b int) int {
 			return a - b
 		},
+		"FlagType":          flagType,
+		"OptionType":        optionType,
+		"OptionField":       optionField,
+		"OptionName":        optionName,
+		"EnvVar":            envVar,
+		"HasFlag":           hasFlag,
+		"HasLiteralDefault": hasLiteralDefault,
+		"Description":       commandDescription,
+		"FlagUsage":         flagUsage,
+		"RequiredFlags":     requiredFlags,
 		"StripNewlines": func(s string) string {
 			return strings.ReplaceAll(s, "\n", "")
 		},
@@ -178,30 +225,46 @@ func defaultFuncMap() template.FuncMap {
 	}
 }
 
+// commandDescription is the help text of the command, which is its long description followed by its example.
+// It's written into a string literal, which the long description is already escaped for.
+func commandDescription(cmd GoCmd) string {
+	long := strings.ReplaceAll(cmd.Long, "\n", "")
+	if cmd.Example == "" {
+		return long
+	}
+	// the help indents the lines after the first, so the example is indented under its heading
+	example := "Example:\n  " + strings.ReplaceAll(cmd.Example, "\n", "\n  ")
+	if long != "" {
+		example = "\n\n" + example
+	}
+	quoted := strconv.Quote(example)
+	return long + quoted[1:len(quoted)-1]
+}
+
 // Generate the main output file
 func GenerateMainFile(opts RunOpts) error {
 	debug := opts.GetLogger()
 	debug.Println("Building local cache...")
-	var err error
-	opts.BinaryFilepath, err = getBinaryFilepath(opts)
-	if err != nil {
-		return err
-	}
 	cwd, err := os.Getwd()
 	if err != nil {
 		return err
 	}
-	gogoFiles, err := findLocalFiles(cwd, gogoFolders)
+	sources, err := layeredSources(opts, cwd)
 	if err != nil {
 		return err
 	}
-	gogoFolder := path.Dir(gogoFiles[0])
-	opts.SourceDir = gogoFolder
-
-	mainFilePath := filepath.Join(opts.SourceDir, MAIN_FILENAME)
-	log.Printf("Building main go file: %v\n", mainFilePath)
-	err = buildSource(true, opts.SourceDir, mainFilePath)
-	return err
+	if len(sources) == 0 {
+		return fmt.Errorf("no gogo files found")
+	}
+	for _, source := range sources {
+		mainFilePath := filepath.Join(source.Dir, MAIN_FILENAME)
+		log.Printf("Building main go file: %v\n", mainFilePath)
+		err = buildSource(true, source.Dir, source.buildFiles(), mainFilePath)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Build reads all the gogo files in the directory, applies their
@@ -210,9 +273,25 @@ func GenerateMainFile(opts RunOpts) error {
 // The output of the binary can be specified in the buildOpts.OutputFilepath
 func Build(log *log.Logger, buildOpts BuildOpts) error {
 	mainFilePath := filepath.Join(buildOpts.SourceDir, MAIN_FILENAME)
+	// tagged files share their directory with the project, so the main file is kept out of it,
+	// and is only placed next to them through an overlay while building
+	var overlayDir string
+	if len(buildOpts.SourceFiles) > 0 {
+		var err error
+		overlayDir, err = os.MkdirTemp("", "gogo-tagged")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if !buildOpts.KeepArtifacts {
+				_ = os.RemoveAll(overlayDir)
+			}
+		}()
+		mainFilePath = filepath.Join(overlayDir, MAIN_FILENAME)
+	}
 	log.Printf("Building main go file: %v\n", mainFilePath)
 
-	err := buildSource(true, buildOpts.SourceDir, mainFilePath)
+	err := buildSource(true, buildOpts.SourceDir, buildOpts.SourceFiles, mainFilePath)
 
 	// delete the main.gogo.go file when we're done
 	defer func(def error) {
@@ -231,11 +310,10 @@ func Build(log *log.Logger, buildOpts BuildOpts) error {
 	}
 
 	// build binary
-	err = buildBinary(buildOpts.Optimize, buildOpts.SourceDir, buildOpts.BinaryFilepath)
-	if err != nil {
-		return err
+	if len(buildOpts.SourceFiles) > 0 {
+		return buildTaggedBinary(buildOpts.Optimize, buildOpts.SourceDir, buildOpts.SourceFiles, mainFilePath, buildOpts.BinaryFilepath)
 	}
-	return nil
+	return buildBinary(buildOpts.Optimize, buildOpts.SourceDir, buildOpts.BinaryFilepath)
 }
 
 // hashString hashes a string using SHA-256
@@ -250,16 +328,35 @@ func hashString(name string) (string, error) {
 }
 
 // buildSource reads all the gogo files in the directory, applies their
-// configuration options, and builds the resulting main file.
-func buildSource(formatOutput bool, inputDir, filePath string) error {
+// configuration options, and builds the resulting main file. If files are
+// given, only those files are read instead of the entire directory.
+func buildSource(formatOutput bool, inputDir string, files []string, filePath string) error {
 	// first we need to parse all functions in the directory that match our build requirements
-	funcs, err := parseDirectory(inputDir)
+	var funcs []function
+	var err error
+	if len(files) > 0 {
+		funcs, err = parseAll(files)
+	} else {
+		funcs, err = parseDirectory(inputDir)
+	}
 	if err != nil {
 		return err
 	}
+	// the subpackages of a gogo folder become command groups
+	if len(files) == 0 {
+		groupFuncs, err := parseGroups(inputDir, parseAll)
+		if err != nil {
+			return err
+		}
+		funcs = append(funcs, groupFuncs...)
+	}
 	if funcs == nil {
 		return fmt.Errorf("no gogo functions found in %v", inputDir)
 	}
+	// the aliases of the functions in command groups are run without the group, so they're checked together
+	if err := checkAliases(funcs); err != nil {
+		return err
+	}
 	// then we need to convert the functions into the renderData
 	rd, err := convertToGoCmds(funcs)
 	if err != nil {
@@ -270,6 +367,11 @@ func buildSource(formatOutput bool, inputDir, filePath string) error {
 	}
 
 	cmd := rd[0]
+	cmd.Imports, err = groupImports(inputDir, funcs)
+	if err != nil {
+		return err
+	}
+	cmd.Imports = append(cmd.Imports, typeImports(funcs)...)
 
 	templateNames := []string{
 		"templates/main.go.tmpl",
@@ -334,6 +436,12 @@ func renderFromTemplates(rd renderData, funcMap map[string]any, templateNames []
 // extraction. This is business logic that the parser should not know about
 // but the builder needs to determine what to print.
 func prepareData(rd renderData) renderData {
+	rd.ImportTime = needsImport(rd.RootCmd, "time")
+	rd.OutputFlag = returnsValue(rd.RootCmd)
+	for _, cmd := range rd.SubCommands {
+		rd.ImportTime = rd.ImportTime || needsImport(cmd, "time")
+		rd.OutputFlag = rd.OutputFlag || returnsValue(cmd)
+	}
 	// determine if we need to include the slices package
 	if hasArgumentRestrictions(rd.RootCmd) {
 		rd.ImportSlices = true
@@ -350,7 +458,16 @@ func prepareData(rd renderData) renderData {
 }
 
 func hasArgumentRestrictions(cmd GoCmd) bool {
+	for _, sub := range cmd.Commands {
+		if hasArgumentRestrictions(sub) {
+			return true
+		}
+	}
 	for _, flag := range cmd.GoFlags {
+		// the other types are checked with gogo.CheckAllowedValues and gogo.CheckRestrictedValues
+		if !slices.Contains(slicesCheckedTypes, flag.Type) {
+			continue
+		}
 		if len(flag.RestrictedValues) > 0 {
 			return true
 		}
@@ -361,8 +478,16 @@ func hasArgumentRestrictions(cmd GoCmd) bool {
 	return false
 }
 
-// buildBinary formats, gets dependencies, and builds the binary
-func buildBinary(optimize bool, sourceDir, to string) error {
+// returnsValue determines if the command, or any of its sub-commands, returns a value
+func returnsValue(cmd GoCmd) bool {
+	if cmd.ValueReturn {
+		return true
+	}
+	return slices.ContainsFunc(cmd.Commands, returnsValue)
+}
+
+// buildBinary formats, gets dependencies, and builds the binary from the entire source directory.
+func buildBinary(optimize bool, sourceDir string, to string) error {
 	// go mod tidy
 	err := sh.Cmd("go", "mod", "tidy").Dir(sourceDir).Run()
 	if err != nil {
@@ -380,6 +505,31 @@ func buildBinary(optimize bool, sourceDir, to string) error {
 		return fmt.Errorf("failed to get dependencies: %w", err)
 	}
 
+	return goBuild(optimize, sourceDir, "", to, sourceDir)
+}
+
+// buildTaggedBinary builds the binary from only the tagged files and the main file, in place, with
+// the project's own module. The main file is placed next to the tagged files through an overlay,
+// and the project's go.mod and go.sum are left as they are, so they must already
+// require everything the files import, including gogo.
+func buildTaggedBinary(optimize bool, sourceDir string, files []string, mainFile string, to string) error {
+	overlayMain := filepath.Join(sourceDir, MAIN_FILENAME)
+	overlay, err := json.Marshal(map[string]map[string]string{
+		"Replace": {overlayMain: mainFile},
+	})
+	if err != nil {
+		return err
+	}
+	overlayFile := filepath.Join(filepath.Dir(mainFile), "overlay.json")
+	if err := os.WriteFile(overlayFile, overlay, 0644); err != nil {
+		return err
+	}
+	return goBuild(optimize, sourceDir, overlayFile, to, append(slices.Clone(files), overlayMain)...)
+}
+
+// goBuild builds the binary from the sources, which are either files or a directory.
+// The overlay file is passed to the go command when it's set.
+func goBuild(optimize bool, sourceDir string, overlayFile string, to string, sources ...string) error {
 	cmd := []string{
 		"go", "build",
 	}
@@ -394,11 +544,16 @@ func buildBinary(optimize bool, sourceDir, to string) error {
 	// add build tags
 	cmd = append(cmd, "-tags=gogo,mage")
 
+	// the overlay is only used to build in place, where the project's go.mod mustn't change, even when GOFLAGS says it can
+	if overlayFile != "" {
+		cmd = append(cmd, "-mod=readonly", "-overlay", overlayFile)
+	}
+
 	// add the output binary
 	cmd = append(cmd, "-o", to)
 
-	// add the source directory
-	cmd = append(cmd, sourceDir)
+	// add the source files, or the entire source directory
+	cmd = append(cmd, sources...)
 
 	// build
 	out, err := sh.Cmd(cmd...).Dir(sourceDir).String()
@@ -16,6 +16,7 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"syscall"
 
 	"github.com/fatih/color"
 	"github.com/muesli/reflow/indent"
@@ -23,9 +24,15 @@ import (
 
 	"github.com/2bit-software/gogo/pkg/fs"
 	"github.com/2bit-software/gogo/pkg/sh"
+	"github.com/2bit-software/gogo/pkg/tags"
 )
 
-const MAIN_FILENAME = "main.gogo.go"
+const (
+	MAIN_FILENAME      = "main.gogo.go"
+	GLOBAL_PREFIX      = "g:"      // forces a function to be resolved from the global namespace
+	GLOBAL_BINARY_NAME = "ggg"     // the name of the binary built from the global namespace
+	PARENT_PREFIX      = "parent:" // skips the nearest layer when resolving a function in layered mode
+)
 
 var (
 	//go:embed templates/*
@@ -34,12 +41,10 @@ var (
 	gogoTags    = []string{"gogo", "mage"}
 )
 
-// TODO: I think this run function needs a cache of parsed files, passed throughout
-//  so that we don't have to reparse the files every time we run a function
-
-// Run this is a simplified version of the Run function in cmd/gogo/main.go
-// For now it only searches for the local gogo files, and does not try to
-// determine if the function exists in the global cache.
+// Run searches for the requested function and runs it. The local namespaces are
+// searched first, and if the function is not found there, the global namespace is used.
+// Prefixing the function with GLOBAL_PREFIX (e.g. `g:funcName`) skips the local search.
+// In layered mode, prefixing the function with PARENT_PREFIX (e.g. `parent:funcName`) skips the nearest layer.
 func Run(opts RunOpts, args []string) error {
 	debug := opts.GetLogger()
 	debug.Printf("Running with %+v\n", opts)
@@ -48,9 +53,7 @@ func Run(opts RunOpts, args []string) error {
 		return BuildLocal(opts)
 	}
 	if opts.BuildGlobalCache {
-		opts.SourceDir = opts.GlobalSourceDir
-		opts.OutputDir = opts.GlobalBinDir
-		return Build(debug, opts.BuildOpts)
+		return BuildGlobal(opts)
 	}
 
 	// determine the outputFilePath if not provided
@@ -58,12 +61,20 @@ func Run(opts RunOpts, args []string) error {
 		opts.OutputDir = "/tmp"
 	}
 	if len(args) == 0 {
-		return fmt.Errorf("no function provided")
+		ran, err := RunDefault(opts)
+		if err != nil {
+			return err
+		}
+		if !ran {
+			return fmt.Errorf("no function provided")
+		}
+		return nil
 	}
 
 	debug.Printf("Running function: %s\n", args[0])
-	funcToRun := args[0]
-	// search for gogo files to run in local namespaces
+	funcToRun, forceGlobal := strings.CutPrefix(args[0], GLOBAL_PREFIX)
+	funcToRun, depth := cutParentPrefix(funcToRun)
+
 	cwd, err := os.Getwd()
 	if err != nil {
 		return err
@@ -71,32 +82,155 @@ func Run(opts RunOpts, args []string) error {
 	if opts.SourceDir != "" {
 		cwd = opts.SourceDir
 	}
+	if !forceGlobal {
+		// search for gogo files to run in local namespaces
+		layers, err := localLayers(opts, cwd)
+		if err != nil {
+			return err
+		}
+		if depth > 0 && depth >= len(layers) {
+			return fmt.Errorf("no parent layer found for %s", args[0])
+		}
+		match, found, err := findLayeredFunc(layers, depth, funcToRun)
+		if err != nil {
+			return err
+		}
+		if found {
+			// a source from an outer layer is always built from its own directory
+			if strings.EqualFold(strings.TrimSpace(opts.SourceDir), "") || match.Layer > 0 {
+				opts.SourceDir = match.Source.Dir
+			}
+			opts.SourceFiles = match.Source.buildFiles()
+
+			opts.BinaryFilepath, err = getBinaryFilepath(opts)
+			if err != nil {
+				return err
+			}
+			return runBinary(debug, opts, binaryArgs(match.Func, args[1:]))
+		}
+	}
 
-	gogoFile, found, err := findLocalFunc(cwd, funcToRun, gogoTags, gogoFolders)
+	// fall back to the global namespace
+	f, found, err := findGlobalFunc(opts, funcToRun)
 	if err != nil {
 		return err
 	}
 	if !found {
-		return fmt.Errorf("function %s not found", funcToRun)
+		return notFoundError(opts, cwd, args[0])
 	}
-	gogoFolder := path.Dir(gogoFile)
-
-	opts.BinaryFilepath, err = getBinaryFilepath(opts)
+	opts.SourceDir = opts.GlobalSourceDir
+	opts.BinaryFilepath, err = getGlobalBinaryFilepath(opts)
 	if err != nil {
 		return err
 	}
+	return runBinary(debug, opts, binaryArgs(f, args[1:]))
+}
+
+// RunDefault runs the default function of the local gadgets, which is set with
+// `var Default = <function>` like in mage. It returns false if there is no default function.
+func RunDefault(opts RunOpts) (bool, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return false, err
+	}
+	if opts.SourceDir != "" {
+		cwd = opts.SourceDir
+	}
+	layers, err := localLayers(opts, cwd)
+	if err != nil {
+		return false, err
+	}
+	for i, layer := range layers {
+		for _, source := range layer.Sources {
+			funcs, err := source.parseFuncs()
+			if err != nil {
+				return false, err
+			}
+			for _, f := range funcs {
+				if f.Default {
+					return true, Run(opts, []string{strings.Repeat(PARENT_PREFIX, i) + f.QualifiedName()})
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+// binaryArgs returns the arguments to run the function with in the built binary. The binary only
+// knows about the function without the namespace prefix or alias, and runs the functions of a
+// command group as subcommands of the group.
+func binaryArgs(f function, args []string) []string {
+	return append(strings.Split(f.QualifiedName(), GROUP_SEPARATOR), args...)
+}
+
+// matchFunc returns the function with the given qualified name, or alias. If neither matches
+// exactly, the name is matched ignoring case, dashes and underscores, so `build-docker` runs `BuildDocker`.
+// When the name could be more than one function, like `build-all` for both `BuildAll` and `Build_all`,
+// none of them is run, and the error lists them.
+func matchFunc(funcs []function, funcToRun string) (function, bool, error) {
+	for _, f := range funcs {
+		if f.QualifiedName() == funcToRun {
+			return f, true, nil
+		}
+	}
+	f, found, err := onlyMatch(funcs, funcToRun, func(f function) bool { return f.hasAlias(funcToRun) })
+	if found || err != nil {
+		return f, found, err
+	}
+	return onlyMatch(funcs, funcToRun, func(f function) bool { return namesMatch(f.QualifiedName(), funcToRun) })
+}
+
+// onlyMatch returns the function that matches, or an error listing the functions when several do
+func onlyMatch(funcs []function, funcToRun string, matches func(function) bool) (function, bool, error) {
+	var found []function
+	for _, f := range funcs {
+		if matches(f) {
+			found = append(found, f)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return function{}, false, nil
+	case 1:
+		return found[0], true, nil
+	}
+	names := make([]string, 0, len(found))
+	for _, f := range found {
+		names = append(names, f.QualifiedName())
+	}
+	return function{}, false, fmt.Errorf("%s is ambiguous, it could be any of: %s", funcToRun, strings.Join(names, ", "))
+}
 
-	if strings.EqualFold(strings.TrimSpace(opts.SourceDir), "") {
-		opts.SourceDir = gogoFolder
+// notFoundError returns the error for a function that could not be found, suggesting the
+// closest function names in case of a typo.
+func notFoundError(opts RunOpts, dir, funcToRun string) error {
+	funcs, err := BuildFuncList(opts, dir)
+	if err != nil {
+		return fmt.Errorf("function %s not found", funcToRun)
+	}
+	var names []string
+	for _, f := range funcs {
+		names = append(names, kebabCase(f.Name))
+		names = append(names, f.Aliases...)
 	}
+	suggestions := suggestNames(names, funcToRun)
+	if len(suggestions) == 0 {
+		return fmt.Errorf("function %s not found", funcToRun)
+	}
+	return fmt.Errorf("function %s not found, did you mean: %s?", funcToRun, strings.Join(suggestions, ", "))
+}
 
-	err = getBuiltBinary(debug, opts.BuildOpts)
+// runBinary builds the binary for opts.SourceDir if necessary, and then runs it with the given arguments.
+func runBinary(debug *log.Logger, opts RunOpts, args []string) error {
+	err := getBuiltBinary(debug, opts.BuildOpts)
 	if err != nil {
 		return err
 	}
 	debug.Printf("Running built binary: %s with args %v\n", opts.BinaryFilepath, args)
 	// run the binary with the desire target func and arguments, unless it exists in the cache
-	ex := sh.Cmd(opts.BinaryFilepath).SetArgs(args...)
+	// a terminal sends Ctrl-C to the binary as well, so gogo waits for it to stop, and passes on a SIGTERM,
+	// which is only sent to gogo, instead of leaving the binary running
+	ex := sh.Cmd(opts.BinaryFilepath).SetArgs(args...).CatchSignals(os.Interrupt).ForwardSignals(syscall.SIGTERM)
 	if opts.Verbose {
 		ex = ex.SetPrintFinalCommand(true)
 	}
@@ -108,47 +242,51 @@ func Run(opts RunOpts, args []string) error {
 		}
 		return fmt.Errorf("%v", errString)
 	}
-
-	// if found, we should check the global cache bin, rebuild if necessary, and run the binary
 	return nil
 }
 
-// BuildLocal searches for the local gogo files, and builds the binary
+// BuildLocal searches for the local gogo files, and builds the binary.
+// If the files are spread across multiple gogo folders, a binary is built for each folder.
 func BuildLocal(opts RunOpts) error {
 	debug := opts.GetLogger()
 	debug.Println("Building local cache...")
-	var err error
-	opts.BinaryFilepath, err = getBinaryFilepath(opts)
-	debug.Println("Output file path:", opts.BinaryFilepath)
-	if err != nil {
-		return err
-	}
 
-	gogoFiles, err := buildRequestedDir(opts)
+	sources, err := buildRequestedDir(opts)
 	if err != nil {
 		return err
 	}
-	if len(gogoFiles) == 0 {
+	if len(sources) == 0 {
 		return fmt.Errorf("no gogo files found")
 	}
 
-	gogoFolder := path.Dir(gogoFiles[0])
-	opts.SourceDir = gogoFolder
-	return Build(debug, opts.BuildOpts)
+	if len(sources) > 1 && opts.BinaryFilepath != "" {
+		return fmt.Errorf("cannot build %d gogo folders into the single output %s", len(sources), opts.BinaryFilepath)
+	}
+	for _, source := range sources {
+		sourceOpts := opts
+		sourceOpts.SourceDir = source.Dir
+		sourceOpts.SourceFiles = source.buildFiles()
+		sourceOpts.BinaryFilepath, err = getBinaryFilepath(sourceOpts)
+		if err != nil {
+			return err
+		}
+		debug.Println("Output file path:", sourceOpts.BinaryFilepath)
+		err = Build(debug, sourceOpts.BuildOpts)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func buildRequestedDir(opts RunOpts) ([]string, error) {
+func buildRequestedDir(opts RunOpts) ([]gadgetSource, error) {
 	// if there is no source dir, assume it's the cwd
 	if opts.SourceDir == "" {
 		cwd, err := os.Getwd()
 		if err != nil {
 			return nil, err
 		}
-		gogoFiles, err := findLocalFiles(cwd, gogoFolders)
-		if err != nil {
-			return nil, err
-		}
-		return gogoFiles, nil
+		return layeredSources(opts, cwd)
 	}
 	opts.GetLogger().Printf("Building requested directory: %s\n", opts.SourceDir)
 
@@ -156,15 +294,37 @@ func buildRequestedDir(opts RunOpts) ([]string, error) {
 	if _, err := os.Stat(opts.SourceDir); os.IsNotExist(err) {
 		return nil, fmt.Errorf("source directory %s does not exist", opts.SourceDir)
 	}
-	gogoFiles, err := findGoFiles(opts.SourceDir)
+	source, err := folderSource(opts.SourceDir)
 	if err != nil {
 		return nil, err
 	}
-	return gogoFiles, nil
+	if len(source.Files) == 0 {
+		return nil, nil
+	}
+	return []gadgetSource{source}, nil
 }
 
+// BuildGlobal builds the binary for the global namespace, located in opts.GlobalSourceDir
 func BuildGlobal(opts RunOpts) error {
-	return nil
+	debug := opts.GetLogger()
+	debug.Println("Building global cache...")
+	if opts.GlobalSourceDir == "" {
+		return fmt.Errorf("no global source directory configured")
+	}
+	globalFiles, err := listGlobalFuncs(opts)
+	if err != nil {
+		return err
+	}
+	if len(globalFiles) == 0 {
+		return fmt.Errorf("no gogo files found in %v", opts.GlobalSourceDir)
+	}
+	opts.SourceDir = opts.GlobalSourceDir
+	opts.BinaryFilepath, err = getGlobalBinaryFilepath(opts)
+	if err != nil {
+		return err
+	}
+	debug.Println("Output file path:", opts.BinaryFilepath)
+	return Build(debug, opts.BuildOpts)
 }
 
 // ShowFuncList lists all the available functions in the local and global namespaces
@@ -182,37 +342,193 @@ func ShowFuncList(opts RunOpts) (int, error) {
 		wd = absPath
 	}
 	// then we are listing the available functions
-	funcList, err := BuildFuncList(opts, wd)
+	layers, globalFuncs, err := buildNamespacedFuncLists(opts, wd)
 	if err != nil {
 		return 0, err
 	}
+	localFuncs := flattenFuncLayers(layers)
 	// sort the functions
-	slices.SortFunc(funcList, func(e, e2 function) int {
-		return strings.Compare(e.Name, e2.Name)
-	})
-	printFuncList(generateFuncListOutput(funcList, opts.ScreenWidth))
-	return len(funcList), nil
+	for _, layer := range layers {
+		sortFuncs(layer.Funcs)
+	}
+	sortFuncs(globalFuncs)
+	// the hidden functions still shadow the ones of the outer layers, they just aren't listed
+	visible := func(funcs []function) []function {
+		return visibleFuncs(funcs, opts.ShowHidden)
+	}
+	localFuncs = visible(localFuncs)
+	// only show the namespace headers when there is more than one namespace to show
+	if len(globalFuncs) == 0 && len(layers) <= 1 {
+		printFuncList(generateFuncListOutput(localFuncs, opts.ScreenWidth))
+		return len(localFuncs), nil
+	}
+	for _, layer := range prefixShadowedLayers(layers) {
+		if len(layers) == 1 {
+			fmt.Println("Local Functions:")
+		} else {
+			fmt.Printf("Local Functions (%s):\n", layer.label(wd))
+		}
+		printFuncList(generateFuncListOutput(visible(layer.Funcs), opts.ScreenWidth))
+		fmt.Println()
+	}
+	globalFuncs = visible(prefixCollisions(globalFuncs, flattenFuncLayers(layers)))
+	if len(globalFuncs) == 0 {
+		return len(localFuncs), nil
+	}
+	fmt.Println("Global Functions:")
+	printFuncList(generateFuncListOutput(globalFuncs, opts.ScreenWidth))
+	return len(localFuncs) + len(globalFuncs), nil
+}
+
+// visibleFuncs returns the functions that aren't hidden, or all of them when the hidden ones are shown too
+func visibleFuncs(funcs []function, showHidden bool) []function {
+	if showHidden {
+		return funcs
+	}
+	var visible []function
+	for _, f := range funcs {
+		if !f.Hidden {
+			visible = append(visible, f)
+		}
+	}
+	return visible
 }
 
 // BuildFuncList builds a list of functions that can be run. It combines
 // both local and global functions. If there are name collisions, the local one
 // takes precedence, and the global one can be used with a prefix.
 // e.g. `gogo g:funcName` would run the global function `funcName`
+// In layered mode, shadowed functions from outer layers are prefixed with PARENT_PREFIX.
 func BuildFuncList(opts RunOpts, dir string) ([]function, error) {
+	layers, globalFuncs, err := buildNamespacedFuncLists(opts, dir)
+	if err != nil {
+		return nil, err
+	}
+	// merge them
+	localFuncs := flattenFuncLayers(prefixShadowedLayers(layers))
+	return append(localFuncs, prefixCollisions(globalFuncs, flattenFuncLayers(layers))...), nil
+}
+
+// funcLayer holds the parsed functions of a single local layer
+type funcLayer struct {
+	gadgetLayer
+	Funcs []function
+}
+
+// label describes where the layer's functions come from, relative to the working directory
+func (l funcLayer) label(wd string) string {
+	var dirs []string
+	for _, source := range l.Sources {
+		dir := source.Dir
+		if rel, err := filepath.Rel(wd, dir); err == nil {
+			dir = rel
+		}
+		dirs = append(dirs, dir)
+	}
+	return strings.Join(dirs, ", ")
+}
+
+// buildNamespacedFuncLists parses the functions in the local layers and global namespace, returning them separately.
+func buildNamespacedFuncLists(opts RunOpts, dir string) ([]funcLayer, []function, error) {
 	if dir == "" {
 		dir = "."
 	}
-	localFiles, err := listLocalFiles(dir, opts.SourceDir != "")
+	layers, err := listLocalLayers(opts, dir, opts.SourceDir != "")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	funcLayers := make([]funcLayer, 0, len(layers))
+	for _, layer := range layers {
+		var funcs []function
+		for _, source := range layer.Sources {
+			sourceFuncs, err := source.parseFuncs()
+			if err != nil {
+				return nil, nil, err
+			}
+			funcs = append(funcs, qualifyNames(sourceFuncs)...)
+		}
+		funcLayers = append(funcLayers, funcLayer{gadgetLayer: layer, Funcs: funcs})
 	}
-	globalFuncs, err := listGlobalFuncs(opts)
+	globalFiles, err := listGlobalFuncs(opts)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	// merge them
-	files := append(localFiles, globalFuncs...)
-	return parseAll(files)
+	// if a local layer is the global one (e.g. running from the home directory), don't list it twice
+	for _, layer := range layers {
+		for _, source := range layer.Sources {
+			if isSameDir(source.Dir, opts.GlobalSourceDir) {
+				return funcLayers, nil, nil
+			}
+		}
+	}
+	if len(globalFiles) == 0 {
+		return funcLayers, nil, nil
+	}
+	globalFuncs, err := gadgetSource{Dir: opts.GlobalSourceDir, Files: globalFiles}.parseFuncs()
+	if err != nil {
+		return nil, nil, err
+	}
+	return funcLayers, qualifyNames(globalFuncs), nil
+}
+
+// flattenFuncLayers returns the functions of all the layers, nearest layer first
+func flattenFuncLayers(layers []funcLayer) []function {
+	var funcs []function
+	for _, layer := range layers {
+		funcs = append(funcs, layer.Funcs...)
+	}
+	return funcs
+}
+
+// prefixShadowedLayers returns a copy of the layers, where any function that is shadowed by a
+// nearer layer is prefixed with PARENT_PREFIX once for each layer it is away from the nearest.
+// e.g. `gogo parent:funcName` runs `funcName` from the first layer above the nearest one
+func prefixShadowedLayers(layers []funcLayer) []funcLayer {
+	prefixed := make([]funcLayer, len(layers))
+	for i, layer := range layers {
+		nearer := flattenFuncLayers(layers[:i])
+		funcs := make([]function, 0, len(layer.Funcs))
+		for _, f := range layer.Funcs {
+			if containsFunc(nearer, f.Name) {
+				f.Name = strings.Repeat(PARENT_PREFIX, i) + f.Name
+			}
+			funcs = append(funcs, f)
+		}
+		layer.Funcs = funcs
+		prefixed[i] = layer
+	}
+	return prefixed
+}
+
+// prefixCollisions returns a copy of the global functions, where any function that
+// is shadowed by a local function is renamed to include the GLOBAL_PREFIX.
+func prefixCollisions(globalFuncs, localFuncs []function) []function {
+	funcs := make([]function, 0, len(globalFuncs))
+	for _, f := range globalFuncs {
+		if containsFunc(localFuncs, f.Name) {
+			f.Name = GLOBAL_PREFIX + f.Name
+		}
+		funcs = append(funcs, f)
+	}
+	return funcs
+}
+
+// containsFunc determines if a function with the given name is in the list
+func containsFunc(funcs []function, name string) bool {
+	return slices.ContainsFunc(funcs, func(f function) bool {
+		return f.Name == name
+	})
+}
+
+// sortFuncs sorts the functions by name, with the functions of each command group listed together
+// after the functions that aren't in a group.
+func sortFuncs(funcs []function) {
+	slices.SortFunc(funcs, func(e, e2 function) int {
+		if c := strings.Compare(e.Group, e2.Group); c != 0 {
+			return c
+		}
+		return strings.Compare(e.Name, e2.Name)
+	})
 }
 
 func getBinaryFilepath(opts RunOpts) (string, error) {
@@ -222,8 +538,12 @@ func getBinaryFilepath(opts RunOpts) (string, error) {
 	// generate filename for this binary
 	// get the name of the current directory
 	dirName := path.Base(opts.OriginalWorkingDir)
-	// hash the directory name
-	hashedDirName, err := hashString(dirName)
+	// hash the source directory, so each gogo folder gets its own binary
+	hashKey := dirName
+	if opts.SourceDir != "" {
+		hashKey = opts.SourceDir
+	}
+	hashedDirName, err := hashString(hashKey)
 	if err != nil {
 		return "", fmt.Errorf("failed to hash directory name: %w", err)
 	}
@@ -248,11 +568,11 @@ func generateFuncListOutput(funcs []function, width int) []string {
 	// Leave some margin
 	width = width - 4
 
-	// Find max name length
+	// Find max name length, of the kebab-case names that are shown
 	maxNameLen := 0
 	for _, f := range funcs {
-		if len(f.Name) > maxNameLen {
-			maxNameLen = len(f.Name)
+		if len(kebabCase(f.Name)) > maxNameLen {
+			maxNameLen = len(kebabCase(f.Name))
 		}
 	}
 
@@ -275,6 +595,7 @@ func generateFuncListOutput(funcs []function, width int) []string {
 		if i%2 == 1 {
 			rowColor = oddRow
 		}
+		name := kebabCase(f.Name)
 
 		// Get description or comment
 		var description string
@@ -285,9 +606,26 @@ func generateFuncListOutput(funcs []function, width int) []string {
 		} else {
 			description = "-"
 		}
+		// show the mage default and aliases, so they can be discovered
+		var extras []string
+		if f.Default {
+			extras = append(extras, "default")
+		}
+		if f.Hidden {
+			extras = append(extras, "hidden")
+		}
+		if len(f.Aliases) > 0 {
+			extras = append(extras, "aliases: "+strings.Join(f.Aliases, ", "))
+		}
+		switch {
+		case len(extras) > 0 && description == "-":
+			description = fmt.Sprintf("(%s)", strings.Join(extras, "; "))
+		case len(extras) > 0:
+			description = fmt.Sprintf("%s (%s)", description, strings.Join(extras, "; "))
+		}
 
 		if description == "-" {
-			lines = append(lines, rowColor.Sprintf("%-*s  %s", maxNameLen, f.Name, description))
+			lines = append(lines, rowColor.Sprintf("%-*s  %s", maxNameLen, name, description))
 			continue
 		}
 
@@ -296,7 +634,7 @@ func generateFuncListOutput(funcs []function, width int) []string {
 		wrappedLines := strings.Split(wrapped, "\n")
 
 		// First line with function name
-		lines = append(lines, rowColor.Sprintf("%-*s  %s", maxNameLen, f.Name, wrappedLines[0]))
+		lines = append(lines, rowColor.Sprintf("%-*s  %s", maxNameLen, name, wrappedLines[0]))
 
 		// Subsequent lines indented (using same color)
 		for _, line := range wrappedLines[1:] {
@@ -308,95 +646,391 @@ func generateFuncListOutput(funcs []function, width int) []string {
 	return lines
 }
 
-// listLocalFiles lists all the local files in the given directory
+// listLocalLayers lists all the local layers for the given directory
 // it normally searches for ".gogo" or "magefiles" directories, but if
 // we set it explicitly, it will only look in that exact directory
-func listLocalFiles(cwd string, exactDir bool) ([]string, error) {
-	if exactDir {
-		return findGoFiles(cwd)
+func listLocalLayers(opts RunOpts, cwd string, exactDir bool) ([]gadgetLayer, error) {
+	if !exactDir {
+		// this returns the layers that match our local search
+		return localLayers(opts, cwd)
+	}
+	source, err := folderSource(cwd)
+	if err != nil {
+		return nil, err
+	}
+	if len(source.Files) == 0 {
+		return nil, nil
 	}
+	return []gadgetLayer{{Dir: cwd, Sources: []gadgetSource{source}}}, nil
+}
+
+// layeredSources returns the sources of all the local layers for the given directory, nearest layer first
+func layeredSources(opts RunOpts, dir string) ([]gadgetSource, error) {
+	layers, err := localLayers(opts, dir)
+	if err != nil {
+		return nil, err
+	}
+	var sources []gadgetSource
+	for _, layer := range layers {
+		sources = append(sources, layer.Sources...)
+	}
+	return sources, nil
+}
+
+// gadgetSource is a directory containing gadget files, which gets built into a single binary.
+type gadgetSource struct {
+	Dir    string   // the directory the binary is built from
+	Files  []string // the .go files containing the gadgets
+	Tagged bool     // when true, Dir is not a gogo folder, and only the tagged Files are built
+}
+
+// buildFiles returns the files that should be built, or nil when the entire directory is built
+func (s gadgetSource) buildFiles() []string {
+	if !s.Tagged {
+		return nil
+	}
+	return s.Files
+}
+
+// parseFuncs parses the functions of the source, including the functions of its command groups. The functions
+// are read from the index when the files haven't changed since they were last parsed.
+func (s gadgetSource) parseFuncs() ([]function, error) {
+	funcs, err := parseIndexed(s.Files)
+	if err != nil {
+		return nil, err
+	}
+	// tagged files share the directory with the rest of the project, so it has no command groups
+	if s.Tagged {
+		return funcs, nil
+	}
+	groupFuncs, err := parseGroups(s.Dir, parseIndexed)
+	if err != nil {
+		return nil, err
+	}
+	return append(funcs, groupFuncs...), nil
+}
+
+// findFunc searches the source for the function, which may be qualified with its command group, or be a mage alias
+func (s gadgetSource) findFunc(funcToRun string) (function, bool, error) {
+	funcs, err := s.parseFuncs()
+	if err != nil {
+		return function{}, false, err
+	}
+	return matchFunc(funcs, funcToRun)
+}
+
+// folderSource returns a source for a gogo folder, where every .go file is part of the build
+func folderSource(dir string) (gadgetSource, error) {
+	files, err := findGoFiles(dir)
+	if err != nil {
+		return gadgetSource{}, err
+	}
+	return gadgetSource{Dir: dir, Files: files}, nil
+}
 
-	// this returns a list of files that match our local search
-	localFiles, err := findLocalFiles(cwd, gogoFolders)
+// findLocalFiles searches for the gogo files in the given directory, and returns all of them.
+// See findLocalSources for how the search is performed.
+func findLocalFiles(dir string, searchFolders []string) ([]string, error) {
+	sources, err := findLocalSources(dir, gogoTags, searchFolders)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, source := range sources {
+		files = append(files, source.Files...)
+	}
+	return files, nil
+}
+
+// gadgetLayer is a set of sources found in the same directory while walking up the tree.
+// In layered mode, every layer between the working directory and the boundary is used,
+// and the nearest layer takes precedence.
+type gadgetLayer struct {
+	Dir     string         // the directory the sources were found from
+	Sources []gadgetSource // the sources found in the directory
+}
+
+// findLocalSources searches for gogo files in the given directory, and returns the sources of the nearest layer.
+// See findLocalLayers for how the search is performed.
+func findLocalSources(dir string, buildTags, searchFolders []string) ([]gadgetSource, error) {
+	layers, _, err := findLocalLayers(dir, false, buildTags, searchFolders)
 	if err != nil {
 		return nil, err
 	}
-	if len(localFiles) == 0 {
-		// convert each
+	if len(layers) == 0 {
 		return nil, nil
 	}
-	// then parse all the funcs, and determine their information
-	return localFiles, nil
+	return layers[0].Sources, nil
 }
 
-// findLocalFiles searches for the gogo files directory in the given directory.
-// If it finds it, it returns a list of all the .go files in that directory.
-// If it does not, it walks up the tree until it either finds it, or:
-// It detects a .git folder, which signifies it's a git root. We assume we don't want to search beyond that
+// findLocalLayers searches for gogo files in the given directory. These are either .go files
+// tagged with one of the build tags, or the .go files in one of the search folders.
+// If it finds them, they become a layer, with a source for each location they were found in.
+// Unless it's layered, it stops at the first layer. Otherwise it keeps walking up the tree collecting
+// layers, nearest first, until either:
+// It finds a .gogobuild file that marks the directory as the root
+// It detects a .git folder, which signifies it's a git root. We assume we don't want to search beyond that,
+// unless a .gogobuild file next to it says to continue
 // It reaches the root of the filesystem
+// A .gogobuild file found before the first layer, or next to it, can also enable layered mode,
+// which it reports along with the layers.
 // We've described other cases in the NOTES.md file, which we may add here.
-func findLocalFiles(dir string, searchFolders []string) ([]string, error) {
+func findLocalLayers(dir string, layered bool, buildTags, searchFolders []string) ([]gadgetLayer, bool, error) {
+	// walking up a relative path never reaches the root of the filesystem
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, false, err
+	}
+	var layers []gadgetLayer
+	for {
+		sources, cfg, stop, err := findSourcesInDir(dir, buildTags, searchFolders)
+		if err != nil {
+			return nil, false, err
+		}
+		layered = layered || cfg.Layered
+		if len(sources) > 0 {
+			layers = append(layers, gadgetLayer{Dir: dir, Sources: sources})
+			// the layers further up are only used in layered mode, so they aren't searched for otherwise
+			if !layered {
+				return layers, false, nil
+			}
+		}
+		if stop {
+			return layers, layered, nil
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// findSourcesInDir returns the sources found in a single directory, along with its .gogobuild config.
+// It also decides whether the search should stop at this directory.
+func findSourcesInDir(dir string, buildTags, searchFolders []string) ([]gadgetSource, buildConfig, bool, error) {
+	// a .gogobuild file overrides the search in this directory
+	cfg, hasConfig, err := readBuildConfig(dir)
+	if err != nil {
+		return nil, cfg, false, err
+	}
+	// TODO: this is not cross-platform compatible, fix that
+	// detect if we're at the root of the filesystem, or the .gogobuild file marks the boundary of the workspace
+	stop := dir == "/" || cfg.Root
+	// if we've detected a git root, we don't want to search beyond that, unless the .gogobuild file
+	// says to continue. A submodule's .git is a file.
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil && !cfg.Continue {
+		stop = true
+	}
+	if hasConfig && len(cfg.sourceDirs(dir)) > 0 {
+		sources, err := cfg.findConfiguredSources(dir)
+		return sources, cfg, stop, err
+	}
+	var sources []gadgetSource
+	// search for tagged files in the directory itself, unless it's already a gogo folder,
+	// in which case it's found as a search folder of the parent directory
+	if !slices.Contains(searchFolders, filepath.Base(dir)) {
+		taggedFiles, err := findTaggedFiles(dir, buildTags)
+		if err != nil {
+			return nil, cfg, false, err
+		}
+		if len(taggedFiles) > 0 {
+			sources = append(sources, gadgetSource{Dir: dir, Files: taggedFiles, Tagged: true})
+		}
+	}
 	// search for the gogo files directory in the current directory
 	files, err := os.ReadDir(dir)
 	if err != nil {
-		return nil, err
+		return nil, cfg, false, err
 	}
-	foundGitRoot := false
+	foundFolder := false
 	for _, file := range files {
-		// if the file is a directory, and it matches one of the search folders, use it
-		if file.IsDir() && slices.Contains(searchFolders, file.Name()) {
-			return findGoFiles(filepath.Join(dir, file.Name()))
-		}
-		if file.IsDir() && file.Name() == ".git" {
-			foundGitRoot = true
+		// if the file is a directory, and it matches one of the search folders, use the first one found
+		if !foundFolder && file.IsDir() && slices.Contains(searchFolders, file.Name()) {
+			source, err := folderSource(filepath.Join(dir, file.Name()))
+			if err != nil {
+				return nil, cfg, false, err
+			}
+			sources = append(sources, source)
+			foundFolder = true
 		}
 	}
-	// TODO: this is not cross-platform compatible, fix that
-	// detect if we're at the root of the filesystem
-	if dir == "/" {
+	return sources, cfg, stop, nil
+}
+
+// findTaggedFiles returns the .go files in the directory which have one of the given build tags.
+// Test files and the generated main file are never considered gadget files.
+func findTaggedFiles(dir string, buildTags []string) ([]string, error) {
+	if len(buildTags) == 0 {
 		return nil, nil
 	}
-	// if we've detected a git root, we don't want to search beyond that
-	if foundGitRoot {
-		return nil, nil
+	goFiles, err := findGoFiles(dir)
+	if err != nil {
+		return nil, err
 	}
-	// search up the tree
-	return findLocalFiles(filepath.Dir(dir), searchFolders)
+	var tagged []string
+	for _, file := range goFiles {
+		name := filepath.Base(file)
+		if strings.HasSuffix(name, "_test.go") || name == MAIN_FILENAME {
+			continue
+		}
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if tags.HasBuildTag(string(src), buildTags) {
+			tagged = append(tagged, file)
+		}
+	}
+	return tagged, nil
 }
 
 // findLocalFunc searches the local environment for the function,
-// and returns the binary to run if it is found.
+// and returns the source that contains it if it is found.
 // What this really means is that:
 // 1. Search for files in the local folder with a +mage tag
 // 2. If none found, search for the following folders in this order: .gogo, gogofiles, magefiles
 // 3. If none found, walk up the local tree and try 3
-// 4. Stop at either a .git folder, or the root of the filesystem
-// 5. Or stop when a .gogo_no_further or some .gogobuild config file, something
-func findLocalFunc(cwd, funcToRun string, tags, codeFolders []string) (string, bool, error) {
-	// this returns a list of files that match our local search
-	localFiles, err := findLocalFiles(cwd, codeFolders)
+// 4. Stop at the first folder they're found in, a .git folder, or the root of the filesystem
+// 5. Or stop when a .gogobuild file sets `root = true`. A .gogobuild file listing the source folders
+// to use replaces the search in its own folder, which is then where they're found
+func findLocalFunc(cwd, funcToRun string, buildTags, codeFolders []string) (gadgetSource, bool, error) {
+	// this returns a list of sources that match our local search
+	sources, err := findLocalSources(cwd, buildTags, codeFolders)
 	if err != nil {
-		return "", false, err
+		return gadgetSource{}, false, err
+	}
+
+	match, found, err := findFuncInSources(sources, funcToRun)
+	return match.Source, found, err
+}
+
+// gadgetMatch is a function found in the local layers, along with where it was found
+type gadgetMatch struct {
+	Source gadgetSource // the source the function is built from
+	Func   function     // the function itself
+	Layer  int          // the index of the layer the source is in
+}
+
+// findFuncInSources returns the first source that contains the function
+func findFuncInSources(sources []gadgetSource, funcToRun string) (gadgetMatch, bool, error) {
+	for _, source := range sources {
+		f, found, err := source.findFunc(funcToRun)
+		if err != nil {
+			return gadgetMatch{}, false, err
+		}
+		if found {
+			return gadgetMatch{Source: source, Func: f}, true, nil
+		}
 	}
-	if len(localFiles) == 0 {
-		return "", false, nil
+	return gadgetMatch{}, false, nil
+}
+
+// findLayeredFunc searches the layers for the function, starting at the given depth,
+// and returns the match from the nearest layer that contains it.
+func findLayeredFunc(layers []gadgetLayer, depth int, funcToRun string) (gadgetMatch, bool, error) {
+	for i := depth; i < len(layers); i++ {
+		match, found, err := findFuncInSources(layers[i].Sources, funcToRun)
+		if err != nil {
+			return gadgetMatch{}, false, err
+		}
+		if found {
+			match.Layer = i
+			return match, true, nil
+		}
 	}
+	return gadgetMatch{}, false, nil
+}
 
-	// determine if target exists in local namespace and use it
-	exists := filesHaveFunc(localFiles, funcToRun)
-	if !exists {
-		return "", false, nil
+// localLayers returns the local layers to use for the given directory. Unless layered mode is
+// enabled, either through the options or a .gogobuild file, only the nearest layer is used.
+func localLayers(opts RunOpts, dir string) ([]gadgetLayer, error) {
+	layers, _, err := findLocalLayers(dir, opts.Layered, gogoTags, gogoFolders)
+	return layers, err
+}
+
+// cutParentPrefix removes any PARENT_PREFIX from the function name, returning how many layers to skip
+func cutParentPrefix(funcName string) (string, int) {
+	depth := 0
+	for {
+		name, found := strings.CutPrefix(funcName, PARENT_PREFIX)
+		if !found {
+			return funcName, depth
+		}
+		funcName = name
+		depth++
 	}
-	return path.Join(localFiles[0]), true, nil
 }
 
+// listGlobalFuncs lists all the go files in the global namespace. The global source
+// directory is itself the gogo folder, so we don't search for one. If the global
+// source directory is not configured or does not exist, there are no global files.
 func listGlobalFuncs(opts RunOpts) ([]string, error) {
-	return nil, nil
+	if opts.GlobalSourceDir == "" {
+		return nil, nil
+	}
+	info, err := os.Stat(opts.GlobalSourceDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("global source %s is not a directory", opts.GlobalSourceDir)
+	}
+	return findGoFiles(opts.GlobalSourceDir)
+}
+
+// findGlobalFunc searches the global namespace for the function,
+// and returns it if it is found.
+func findGlobalFunc(opts RunOpts, funcToRun string) (function, bool, error) {
+	globalFiles, err := listGlobalFuncs(opts)
+	if err != nil {
+		return function{}, false, err
+	}
+	if len(globalFiles) == 0 {
+		return function{}, false, nil
+	}
+	return gadgetSource{Dir: opts.GlobalSourceDir, Files: globalFiles}.findFunc(funcToRun)
 }
 
-// TODO: implement this
-func findGlobalFunc(opts RunOpts, funcToRun string) (string, bool, error) {
-	return "", false, nil
+// getGlobalBinaryFilepath returns the location of the binary built from the global namespace.
+// Unless GlobalBinDir is set, it lives in the user cache dir, keyed by the global source dir,
+// so that different users and different global namespaces never share a binary.
+func getGlobalBinaryFilepath(opts RunOpts) (string, error) {
+	if opts.GlobalBinDir != "" {
+		return filepath.Join(opts.GlobalBinDir, GLOBAL_BINARY_NAME), nil
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to find the user cache dir: %w", err)
+	}
+	sourceDir, err := filepath.Abs(opts.GlobalSourceDir)
+	if err != nil {
+		return "", err
+	}
+	hash, err := hashString(sourceDir)
+	if err != nil {
+		return "", err
+	}
+	binDir := filepath.Join(cacheDir, "gogo", "global-"+hash)
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		return "", err
+	}
+	return filepath.Join(binDir, GLOBAL_BINARY_NAME), nil
+}
+
+// isSameDir determines if both paths point to the same directory
+func isSameDir(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	absA, err := filepath.Abs(a)
+	if err != nil {
+		return false
+	}
+	absB, err := filepath.Abs(b)
+	if err != nil {
+		return false
+	}
+	return absA == absB
 }
 
 // findGoFiles searches for all .go files in the given directory.
@@ -437,7 +1071,7 @@ func getBuiltBinary(log *log.Logger, buildOpts BuildOpts) error {
 
 // decideToRebuild determines if we should rebuild the binary based on the source files and the binary file
 func decideToRebuild(debug *log.Logger, buildOpts BuildOpts) bool {
-	sourceFiles, modified := gatherFilesToCompare(debug, buildOpts.SourceDir)
+	sourceFiles, modified := gatherFilesToCompare(debug, buildOpts.SourceDir, buildOpts.SourceFiles)
 	debug.Printf("Found the following source files: %v\n", sourceFiles)
 	if modified {
 		return true
@@ -464,7 +1098,17 @@ func decideToRebuild(debug *log.Logger, buildOpts BuildOpts) bool {
 	return false
 }
 
-func gatherFilesToCompare(debug *log.Logger, dir string) ([]string, bool) {
+// gatherFilesToCompare lists the files in the directory that the binary depends on. If files are given,
+// only they and the go module files are compared, since the rest of the directory is not part of the build.
+func gatherFilesToCompare(debug *log.Logger, dir string, files []string) ([]string, bool) {
+	if len(files) > 0 {
+		modFiles, err := fs.GlobMany([]string{dir}, []string{"go.mod", "go.sum"})
+		if err != nil {
+			debug.Printf("Error finding files to glob: %v\n", err)
+			return nil, true
+		}
+		return append(slices.Clone(files), modFiles...), false
+	}
 	sourceFiles, err := fs.GlobMany([]string{dir}, []string{"*.go", "go.mod", "go.sum"})
 	// if there's an error with the comparison, just build it
 	if err != nil {
@@ -520,25 +1164,37 @@ func convertToGoCmds(funcs []function) ([]renderData, error) {
 
 	// if there are multiple functions, we need to create a root command
 	// and then add the functions as subcommands
-	rd.SubCommands = make([]GoCmd, len(funcs))
-	for i, funk := range funcs {
-		rd.SubCommands[i] = convertToGoCmd(funk)
-		if rd.SubCommands[i].UseGoGoContext {
+	// functions in a command group are nested within a command for each level of the group
+	for _, funk := range funcs {
+		cmd := convertToGoCmd(funk)
+		if cmd.UseGoGoContext {
 			rd.UseGoGoContext = true
 		}
+		var groups []string
+		if funk.Group != "" {
+			groups = strings.Split(funk.Group, GROUP_SEPARATOR)
+		}
+		rd.SubCommands = addToGroup(rd.SubCommands, groups, funk.GroupDescription, cmd)
+		if funk.Group != "" {
+			for _, alias := range funk.Aliases {
+				rd.GroupAliases = append(rd.GroupAliases, GroupAlias{Alias: alias, Args: binaryArgs(funk, nil)})
+			}
+		}
 	}
 	return []renderData{rd}, nil
 }
 
+// cleanup makes the description fit on a single line of a string literal in the generated code
+func cleanup(s string) string {
+	// remove newlines
+	s = strings.ReplaceAll(s, "\n", " ")
+	// escape quotes
+	s = strings.ReplaceAll(s, "\"", "\\\"")
+	return s
+}
+
 // convertToGoCmd converts a function to a GoCmd
 func convertToGoCmd(funk function) GoCmd {
-	cleanup := func(s string) string {
-		// remove newlines
-		s = strings.ReplaceAll(s, "\n", " ")
-		// escape quotes
-		s = strings.ReplaceAll(s, "\"", "\\\"")
-		return s
-	}
 	cmd := GoCmd{
 		Name:           funk.Name,
 		Short:          cleanup(funk.Description),
@@ -546,7 +1202,23 @@ func convertToGoCmd(funk function) GoCmd {
 		Example:        funk.Example,
 		GoFlags:        nil,
 		ErrorReturn:    funk.ErrorReturn,
+		ValueReturn:    funk.ReturnType != "",
+		Stream:         funk.Stream,
 		UseGoGoContext: funk.UseGoGoCtx,
+		Receiver:       funk.Receiver,
+		Hidden:         funk.Hidden,
+		Aliases:        commandAliases(funk.Name),
+		Function:       true,
+	}
+	// aliases set with ctx.Alias or a mage Aliases variable are also registered, so the binary accepts them too.
+	// The aliases of a function in a command group also run it without the group, see renderData.GroupAliases.
+	for _, alias := range funk.Aliases {
+		if alias != funk.Name && !slices.Contains(cmd.Aliases, alias) {
+			cmd.Aliases = append(cmd.Aliases, alias)
+		}
+	}
+	if funk.Package != "" {
+		cmd.Package = groupAlias(funk.Package)
 	}
 	// now for each of the flags, convert them to GoFlags
 	for _, argProperties := range funk.Arguments {
@@ -554,37 +1226,95 @@ func convertToGoCmd(funk function) GoCmd {
 		if argProperties.Type == "gogo.Context" {
 			continue
 		}
-		flag := GoFlag{
-			Type: argProperties.Type,
-			Name: argProperties.Name,
-		}
-		flag.Default = argProperties.Default
-		flag.HasDefault = argProperties.Default != nil
-		if argProperties.Default == nil {
-			switch argProperties.Type {
-			case "bool":
-				flag.Default = false
-			case "float64":
-				fallthrough
-			case "int":
-				flag.Default = 0
-			case "string":
-				flag.Default = `""`
+		// an options struct has a flag for each of its fields, instead of being a flag itself
+		if argProperties.Fields != nil {
+			cmd.Options = localType(funk, argProperties.Type)
+			for _, field := range argProperties.Fields {
+				flag := convertToGoFlag(funk, field)
+				flag.Name = field.Long
+				flag.Field = field.Name
+				flag.Env = field.Env
+				// the help is also used in the struct tag of the generated Options, which is a raw string
+				flag.Help = strings.ReplaceAll(cleanup(flag.Help), "`", "'")
+				cmd.GoFlags = append(cmd.GoFlags, flag)
 			}
+			continue
 		}
-		if argProperties.AllowedValues != nil {
-			flag.AllowedValues = argProperties.AllowedValues
-		}
-		if argProperties.RestrictedValues != nil {
-			flag.RestrictedValues = argProperties.RestrictedValues
+		// the variadic parameter isn't a flag, it takes whatever positional arguments are left
+		if argProperties.Variadic {
+			flag := convertToGoFlag(funk, argProperties)
+			flag.Type = strings.TrimPrefix(flag.Type, VARIADIC_PREFIX)
+			cmd.Variadic = &flag
+			continue
 		}
-		if argProperties.Help != "" {
-			flag.Help = argProperties.Help
+		cmd.GoFlags = append(cmd.GoFlags, convertToGoFlag(funk, argProperties))
+	}
+	return cmd
+}
+
+// localType returns how the generated main package refers to a type used by the function. A type declared
+// in a command group's package is qualified with the package's alias.
+func localType(funk function, typ string) string {
+	if funk.Package == "" {
+		return typ
+	}
+	return groupAlias(funk.Package) + "." + typ
+}
+
+// anyValues converts the values of an argument to the values of its flag
+func anyValues(values []string) []any {
+	converted := make([]any, 0, len(values))
+	for _, value := range values {
+		converted = append(converted, value)
+	}
+	return converted
+}
+
+// convertToGoFlag converts an argument of the function to a GoFlag
+func convertToGoFlag(funk function, argProperties argument) GoFlag {
+	flag := GoFlag{
+		Type:       argProperties.Type,
+		Name:       argProperties.Name,
+		TextType:   argProperties.Text,
+		Underlying: argProperties.Underlying,
+	}
+	// a type declared in a command group's package is used from the generated main package
+	if (argProperties.Text || argProperties.Underlying != "") && argProperties.TypeImport == "" {
+		flag.Type = localType(funk, argProperties.Type)
+	}
+	flag.Default = argProperties.Default
+	flag.HasDefault = argProperties.Default != ""
+	if argProperties.Default == "" {
+		switch argProperties.Type {
+		case "bool":
+			flag.Default = false
+		case "float64":
+			fallthrough
+		case "int":
+			flag.Default = 0
+		case "string":
+			flag.Default = `""`
 		}
-		if argProperties.Short != byte(0) {
-			flag.Short = argProperties.Short
+	}
+	if argProperties.AllowedValues != nil {
+		flag.AllowedValues = anyValues(argProperties.AllowedValues)
+	}
+	if argProperties.RestrictedValues != nil {
+		flag.RestrictedValues = anyValues(argProperties.RestrictedValues)
+	}
+	if argProperties.Help != "" {
+		flag.Help = argProperties.Help
+	}
+	// the values of an enum are described by the doc comments of their constants
+	if values := enumHelp(argProperties.Enum, argProperties.AllowedValues); values != "" {
+		if flag.Help != "" {
+			flag.Help += "; "
 		}
-		cmd.GoFlags = append(cmd.GoFlags, flag)
+		flag.Help += values
 	}
-	return cmd
+	if argProperties.Short != byte(0) {
+		flag.Short = argProperties.Short
+	}
+	flag.Required = argProperties.Required
+	return flag
 }
@@ -101,6 +101,210 @@ func TestRenderTemplates(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "command group",
+			renderData: renderData{
+				Imports: []GoImport{
+					{Alias: "dockerGadgets", Path: "github.com/example/gadgets/docker"},
+				},
+				SubCommands: []GoCmd{
+					{
+						Name:  "docker",
+						Short: "docker commands",
+						Commands: []GoCmd{
+							{
+								Name:    "Build",
+								Package: "dockerGadgets",
+								Aliases: []string{"build", "db"},
+								GoFlags: []GoFlag{
+									{
+										Type: "string",
+										Name: "tag",
+									},
+								},
+							},
+						},
+					},
+				},
+				GroupAliases: []GroupAlias{
+					{Alias: "db", Args: []string{"docker", "Build"}},
+				},
+			},
+		},
+		{
+			name: "argument types",
+			renderData: renderData{
+				ImportTime: true,
+				SubCommands: []GoCmd{
+					{
+						Name: "Deploy",
+						GoFlags: []GoFlag{
+							{
+								Type:          "[]string",
+								Name:          "services",
+								AllowedValues: []any{"api", "worker"},
+							},
+							{
+								Type:       "time.Duration",
+								Name:       "timeout",
+								Default:    "30s",
+								HasDefault: true,
+							},
+							{
+								Type: "time.Time",
+								Name: "since",
+							},
+							{
+								Type:             "uint",
+								Name:             "replicas",
+								RestrictedValues: []any{"0"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "options struct",
+			renderData: renderData{
+				UseGoGoContext: true,
+				ImportTime:     true,
+				SubCommands: []GoCmd{
+					{
+						Name:           "Release",
+						ErrorReturn:    true,
+						UseGoGoContext: true,
+						Options:        "ReleaseOpts",
+						GoFlags: []GoFlag{
+							{
+								Type:  "string",
+								Name:  "version",
+								Field: "Version",
+								Short: 'v',
+								Help:  "Version is the version to release",
+							},
+							{
+								Type:  "bool",
+								Name:  "dry-run",
+								Field: "DryRun",
+							},
+							{
+								Type:       "time.Duration",
+								Name:       "timeout",
+								Field:      "Timeout",
+								Default:    "5m",
+								HasDefault: true,
+								Env:        "RELEASE_TIMEOUT",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "variadic argument",
+			renderData: renderData{
+				SubCommands: []GoCmd{
+					{
+						Name: "Exec",
+						GoFlags: []GoFlag{
+							{
+								Type: "string",
+								Name: "cmd",
+							},
+						},
+						Variadic: &GoFlag{
+							Type: "string",
+							Name: "args",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "value return",
+			renderData: renderData{
+				OutputFlag: true,
+				SubCommands: []GoCmd{
+					{
+						Name:        "Services",
+						ErrorReturn: true,
+						ValueReturn: true,
+						GoFlags: []GoFlag{
+							{
+								Type: "string",
+								Name: "env",
+							},
+						},
+					},
+					{
+						Name:        "Render",
+						ValueReturn: true,
+						GoFlags: []GoFlag{
+							{
+								Type: "string",
+								Name: "output",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "stream return",
+			renderData: renderData{
+				OutputFlag: true,
+				SubCommands: []GoCmd{
+					{
+						Name:        "Tail",
+						ValueReturn: true,
+						Stream:      STREAM_SEQ2,
+						GoFlags: []GoFlag{
+							{
+								Type: "int",
+								Name: "lines",
+							},
+						},
+					},
+					{
+						Name:           "Watch",
+						ErrorReturn:    true,
+						ValueReturn:    true,
+						Stream:         STREAM_CHAN,
+						UseGoGoContext: true,
+					},
+				},
+			},
+		},
+		{
+			name: "text unmarshaler arguments",
+			renderData: renderData{
+				Imports: []GoImport{
+					{Alias: "netip", Path: "net/netip"},
+				},
+				SubCommands: []GoCmd{
+					{
+						Name: "Ping",
+						GoFlags: []GoFlag{
+							{
+								Type:     "netip.Addr",
+								Name:     "addr",
+								TextType: true,
+							},
+							{
+								Type:     "Env",
+								Name:     "env",
+								TextType: true,
+							},
+							{
+								Type: "url.URL",
+								Name: "endpoint",
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	templateNames := []string{
@@ -2,11 +2,13 @@ package gadgets
 
 import (
 	"github.com/2bit-software/gogo/pkg/mod"
+	"github.com/2bit-software/gogo/pkg/sh"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"log"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -38,8 +40,8 @@ func TestDescription(t *testing.T) {
 	require.NoError(t, err)
 	require.NoError(t, err)
 	output := generateFuncListOutput(funcList, 300)
-	// assert that the output contains the expected description
-	description := `This is the description for the function. Without any other arguments to the ctx, this will show up in the list view and the --help output.`
+	// assert that the output contains the expected description, which is the first sentence of the doc comment
+	description := `This is the description for the function.`
 	found := false
 	for _, out := range output {
 		if strings.Contains(out, description) {
@@ -107,3 +109,305 @@ func TestBuild(t *testing.T) {
 	err = Build(l, opts)
 	require.NoError(t, err)
 }
+
+// Test that global functions are listed alongside the local ones, and that
+// global functions shadowed by a local function are listed with the global prefix.
+func TestBuildFuncListGlobal(t *testing.T) {
+	root, err := mod.FindModuleRoot()
+	require.NoError(t, err)
+
+	opts := RunOpts{
+		GlobalSourceDir: path.Join(root, "scenarios", "global"),
+	}
+	funcList, err := BuildFuncList(opts, path.Join(root, "scenarios", "standard"))
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(funcList))
+	for _, f := range funcList {
+		names = append(names, f.Name)
+	}
+	assert.Contains(t, names, "SingleArgument")
+	assert.Contains(t, names, "GlobalOnly")
+	assert.Contains(t, names, GLOBAL_PREFIX+"SingleArgument")
+	assert.NotContains(t, names, GLOBAL_PREFIX+"GlobalOnly")
+}
+
+func TestFindGlobalFunc(t *testing.T) {
+	root, err := mod.FindModuleRoot()
+	require.NoError(t, err)
+	globalDir := path.Join(root, "scenarios", "global")
+
+	tests := []struct {
+		name     string
+		opts     RunOpts
+		funcName string
+		found    bool
+	}{
+		{
+			name:     "global function",
+			opts:     RunOpts{GlobalSourceDir: globalDir},
+			funcName: "GlobalOnly",
+			found:    true,
+		},
+		{
+			name:     "missing function",
+			opts:     RunOpts{GlobalSourceDir: globalDir},
+			funcName: "DescriptionOnly",
+			found:    false,
+		},
+		{
+			name:     "no global source dir",
+			opts:     RunOpts{},
+			funcName: "GlobalOnly",
+			found:    false,
+		},
+		{
+			name:     "global source dir does not exist",
+			opts:     RunOpts{GlobalSourceDir: path.Join(globalDir, "does-not-exist")},
+			funcName: "GlobalOnly",
+			found:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, found, err := findGlobalFunc(tt.opts, tt.funcName)
+			require.NoError(t, err)
+			assert.Equal(t, tt.found, found)
+			if tt.found {
+				assert.Equal(t, tt.funcName, f.Name)
+			}
+		})
+	}
+}
+
+func TestBuildGlobal(t *testing.T) {
+	root, err := mod.FindModuleRoot()
+	require.NoError(t, err)
+	// make a temp dir
+	tmpDir, err := os.MkdirTemp("", "gogo-test")
+	require.NoError(t, err)
+
+	opts := RunOpts{
+		GlobalSourceDir: path.Join(root, "scenarios", "global"),
+		GlobalBinDir:    tmpDir,
+		BuildOpts: BuildOpts{
+			DisableCache: true,
+		},
+	}
+
+	// build the global namespace
+	err = BuildGlobal(opts)
+	require.NoError(t, err)
+	assert.FileExists(t, path.Join(tmpDir, GLOBAL_BINARY_NAME))
+}
+
+// Test that the global binary defaults to the user cache dir, keyed by the global source dir
+func TestGlobalBinaryFilepath(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+
+	first, err := getGlobalBinaryFilepath(RunOpts{GlobalSourceDir: "/home/first/.gogo"})
+	require.NoError(t, err)
+	second, err := getGlobalBinaryFilepath(RunOpts{GlobalSourceDir: "/home/second/.gogo"})
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(cacheDir, "gogo"), filepath.Dir(filepath.Dir(first)))
+	assert.Equal(t, GLOBAL_BINARY_NAME, filepath.Base(first))
+	assert.NotEqual(t, first, second)
+	assert.DirExists(t, filepath.Dir(first))
+
+	override, err := getGlobalBinaryFilepath(RunOpts{GlobalSourceDir: "/home/first/.gogo", GlobalBinDir: "/opt/bin"})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/opt/bin", GLOBAL_BINARY_NAME), override)
+}
+
+// Test that files tagged with a gogo build tag are found outside the gogo folders,
+// without picking up the other go files in the same directory.
+func TestFindLocalSourcesTagged(t *testing.T) {
+	root, err := mod.FindModuleRoot()
+	require.NoError(t, err)
+	scenarioDir := path.Join(root, "scenarios", "tagged")
+
+	sources, err := findLocalSources(scenarioDir, gogoTags, gogoFolders)
+	require.NoError(t, err)
+	require.Len(t, sources, 1)
+	assert.True(t, sources[0].Tagged)
+	assert.Equal(t, scenarioDir, sources[0].Dir)
+	assert.ElementsMatch(t, []string{
+		path.Join(scenarioDir, "gogofile.go"),
+		path.Join(scenarioDir, "magefile.go"),
+	}, sources[0].Files)
+
+	// files inside a gogo folder are found through the folder, even when tagged
+	sources, err = findLocalSources(path.Join(root, "scenarios", "standard", ".gogo"), gogoTags, gogoFolders)
+	require.NoError(t, err)
+	require.Len(t, sources, 1)
+	assert.False(t, sources[0].Tagged)
+}
+
+func TestBuildTagged(t *testing.T) {
+	l := log.New(os.Stdout, "", log.LstdFlags)
+	root, err := mod.FindModuleRoot()
+	require.NoError(t, err)
+	scenarioDir := path.Join(root, "scenarios", "tagged")
+	tmpDir := t.TempDir()
+
+	sources, err := findLocalSources(scenarioDir, gogoTags, gogoFolders)
+	require.NoError(t, err)
+	require.Len(t, sources, 1)
+
+	opts := BuildOpts{
+		DisableCache:   true,
+		SourceDir:      sources[0].Dir,
+		SourceFiles:    sources[0].buildFiles(),
+		BinaryFilepath: path.Join(tmpDir, "gadgets"),
+	}
+	// even when the go command is allowed to update the go.mod
+	t.Setenv("GOFLAGS", "-mod=mod")
+	gomod, err := os.ReadFile(path.Join(scenarioDir, "go.mod"))
+	require.NoError(t, err)
+	gosum, err := os.ReadFile(path.Join(scenarioDir, "go.sum"))
+	require.NoError(t, err)
+	err = Build(l, opts)
+	require.NoError(t, err)
+
+	// the tagged files are built in place, without changing the project's module or leaving the main file behind
+	assert.NoFileExists(t, path.Join(scenarioDir, MAIN_FILENAME))
+	after, err := os.ReadFile(path.Join(scenarioDir, "go.mod"))
+	require.NoError(t, err)
+	assert.Equal(t, string(gomod), string(after))
+	after, err = os.ReadFile(path.Join(scenarioDir, "go.sum"))
+	require.NoError(t, err)
+	assert.Equal(t, string(gosum), string(after))
+
+	out, err := sh.Cmd(opts.BinaryFilepath).SetArgs("GoGoTagged", "tagged").String()
+	require.NoError(t, err)
+	assert.Equal(t, "GoGoTagged with name: tagged", strings.TrimSpace(out))
+	// the binary accepts the same forms of the name as gogo does
+	for _, name := range []string{"MageTagged", "mage-tagged", "mage_tagged", "magetagged"} {
+		out, err = sh.Cmd(opts.BinaryFilepath).SetArgs(name).String()
+		require.NoError(t, err)
+		assert.Equal(t, "MageTagged v1.2.3", strings.TrimSpace(out))
+	}
+}
+
+func TestFindLocalLayers(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0755))
+	writeTree(t, root, map[string]string{
+		".gogo/shared.go":         "package main\nfunc Lint() {}\nfunc Release() {}",
+		"svc/.gogo/svc.go":        "package main\nfunc Lint() {}\nfunc Deploy() {}",
+		"svc/cmd/placeholder.txt": "",
+	})
+	startDir := filepath.Join(root, "svc", "cmd")
+
+	layers, layered, err := findLocalLayers(startDir, true, gogoTags, gogoFolders)
+	require.NoError(t, err)
+	assert.True(t, layered)
+	require.Len(t, layers, 2)
+	assert.Equal(t, filepath.Join(root, "svc"), layers[0].Dir)
+	assert.Equal(t, root, layers[1].Dir)
+
+	// only the nearest layer is searched for unless layered mode is enabled
+	layers, layered, err = findLocalLayers(startDir, false, gogoTags, gogoFolders)
+	require.NoError(t, err)
+	assert.False(t, layered)
+	require.Len(t, layers, 1)
+	assert.Equal(t, filepath.Join(root, "svc"), layers[0].Dir)
+	nearest, err := localLayers(RunOpts{}, startDir)
+	require.NoError(t, err)
+	assert.Len(t, nearest, 1)
+
+	// so a broken .gogobuild further up doesn't matter
+	writeTree(t, root, map[string]string{".gogobuild": "root = "})
+	nearest, err = localLayers(RunOpts{}, startDir)
+	require.NoError(t, err)
+	assert.Len(t, nearest, 1)
+	require.NoError(t, os.Remove(filepath.Join(root, ".gogobuild")))
+
+	funcs, err := BuildFuncList(RunOpts{Layered: true}, startDir)
+	require.NoError(t, err)
+	var names []string
+	for _, f := range funcs {
+		names = append(names, f.Name)
+	}
+	assert.ElementsMatch(t, []string{"Lint", "Deploy", "parent:Lint", "Release"}, names)
+
+	// the parent prefix skips the nearest layer
+	all, err := localLayers(RunOpts{Layered: true}, startDir)
+	require.NoError(t, err)
+	funcName, depth := cutParentPrefix("parent:Lint")
+	match, found, err := findLayeredFunc(all, depth, funcName)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 1, match.Layer)
+	assert.Equal(t, filepath.Join(root, ".gogo"), match.Source.Dir)
+	_, found, err = findLayeredFunc(all, 0, "Release")
+	require.NoError(t, err)
+	assert.True(t, found)
+}
+
+func TestFindLocalLayersConfig(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0755))
+	writeTree(t, root, map[string]string{
+		".gogo/shared.go":  "package main\nfunc Release() {}",
+		"svc/.gogobuild":   "layered = true",
+		"svc/.gogo/svc.go": "package main\nfunc Deploy() {}",
+	})
+
+	layers, err := localLayers(RunOpts{}, filepath.Join(root, "svc"))
+	require.NoError(t, err)
+	assert.Len(t, layers, 2)
+
+	// a .gogobuild further up than the nearest layer isn't read without layered mode
+	writeTree(t, root, map[string]string{".gogobuild": "layered = true", "svc/.gogobuild": ""})
+	layers, err = localLayers(RunOpts{}, filepath.Join(root, "svc"))
+	require.NoError(t, err)
+	assert.Len(t, layers, 1)
+
+	// a root config is the boundary for the layers
+	writeTree(t, root, map[string]string{"svc/.gogobuild": "root = true"})
+	layers, err = localLayers(RunOpts{Layered: true}, filepath.Join(root, "svc"))
+	require.NoError(t, err)
+	assert.Len(t, layers, 1)
+}
+
+// Test that a submodule's .git file stops the search, unless its .gogobuild says to continue
+func TestFindLocalLayersSubmodule(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0755))
+	writeTree(t, root, map[string]string{
+		".gogo/shared.go":         "package main\nfunc Release() {}",
+		"lib/.git":                "gitdir: ../.git/modules/lib",
+		"lib/src/placeholder.txt": "",
+	})
+	startDir := filepath.Join(root, "lib", "src")
+
+	layers, _, err := findLocalLayers(startDir, false, gogoTags, gogoFolders)
+	require.NoError(t, err)
+	assert.Empty(t, layers)
+
+	writeTree(t, root, map[string]string{"lib/.gogobuild": "continue = true"})
+	layers, _, err = findLocalLayers(startDir, false, gogoTags, gogoFolders)
+	require.NoError(t, err)
+	require.Len(t, layers, 1)
+	assert.Equal(t, root, layers[0].Dir)
+
+	// the repo's own .git folder still stops the search
+	layers, _, err = findLocalLayers(startDir, true, gogoTags, gogoFolders)
+	require.NoError(t, err)
+	assert.Len(t, layers, 1)
+}
+
+func TestVisibleFuncs(t *testing.T) {
+	funcs := []function{{Name: "Build"}, {Name: "Cleanup", Hidden: true}}
+	assert.Equal(t, []function{{Name: "Build"}}, visibleFuncs(funcs, false))
+	assert.Equal(t, funcs, visibleFuncs(funcs, true))
+
+	// the hidden functions are marked when they're listed
+	output := generateFuncListOutput(funcs, 300)
+	require.Len(t, output, 2)
+	assert.Contains(t, output[1], "cleanup")
+	assert.Contains(t, output[1], "(hidden)")
+}
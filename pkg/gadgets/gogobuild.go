@@ -0,0 +1,90 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+const BUILD_CONFIG_FILENAME = ".gogobuild"
+
+// buildConfig is the configuration read from a .gogobuild file. The file is found while
+// walking up the tree searching for gogo files, and changes how that search behaves.
+//
+// Example:
+//
+//	# stop searching parent directories after this one
+//	root = true
+//	# use these folders instead of searching for a .gogo folder
+//	sources = ["tools/gadgets", "ci/gadgets"]
+//	# use the gadgets of every layer up to the boundary, not only the nearest
+//	layered = true
+//	# keep searching past the .git folder or file in this directory, like in a submodule
+//	continue = true
+type buildConfig struct {
+	Root     bool     `toml:"root"`     // When true, the search does not continue into parent directories
+	Continue bool     `toml:"continue"` // When true, a .git folder or file in this directory doesn't stop the search
+	Source   string   `toml:"source"`   // A gadget folder to use, relative to the .gogobuild file
+	Sources  []string `toml:"sources"`  // Several gadget folders to use, relative to the .gogobuild file
+	Layered  bool     `toml:"layered"`  // When true, the gadgets of every layer up to the boundary are available
+}
+
+// readBuildConfig reads the .gogobuild file in the given directory, if it exists.
+func readBuildConfig(dir string) (buildConfig, bool, error) {
+	var cfg buildConfig
+	configPath := filepath.Join(dir, BUILD_CONFIG_FILENAME)
+	_, err := toml.DecodeFile(configPath, &cfg)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, false, nil
+	}
+	if err != nil {
+		return cfg, false, fmt.Errorf("failed to read %s: %w", configPath, err)
+	}
+	return cfg, true, nil
+}
+
+// sourceDirs returns the absolute paths of all the gadget folders listed in the config.
+// Relative paths are resolved against the directory containing the .gogobuild file.
+func (cfg buildConfig) sourceDirs(dir string) []string {
+	var dirs []string
+	if cfg.Source != "" {
+		dirs = append(dirs, cfg.Source)
+	}
+	dirs = append(dirs, cfg.Sources...)
+	for i, d := range dirs {
+		if !filepath.IsAbs(d) {
+			dirs[i] = filepath.Join(dir, d)
+		}
+	}
+	return dirs
+}
+
+// findConfiguredSources returns a source for each of the gadget folders listed in the config.
+func (cfg buildConfig) findConfiguredSources(dir string) ([]gadgetSource, error) {
+	var sources []gadgetSource
+	for _, sourceDir := range cfg.sourceDirs(dir) {
+		info, err := os.Stat(sourceDir)
+		if err != nil {
+			return nil, fmt.Errorf("source %s in %s: %w", sourceDir, filepath.Join(dir, BUILD_CONFIG_FILENAME), err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("source %s in %s is not a directory", sourceDir, filepath.Join(dir, BUILD_CONFIG_FILENAME))
+		}
+		source, err := folderSource(sourceDir)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
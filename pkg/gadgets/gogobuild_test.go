@@ -0,0 +1,151 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTree creates the given files (relative path -> contents) under root
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, contents := range files {
+		p := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(contents), 0644))
+	}
+}
+
+func TestFindLocalFilesWithBuildConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		startDir string
+		expected []string
+		err      bool
+	}{
+		{
+			name: "root stops the search",
+			files: map[string]string{
+				".gogo/outer.go":              "package main\nfunc Outer() {}",
+				"project/.gogobuild":          "root = true",
+				"project/sub/placeholder.txt": "",
+			},
+			startDir: "project/sub",
+			expected: nil,
+		},
+		{
+			name: "gogo folder next to a root config is still used",
+			files: map[string]string{
+				".gogo/outer.go":         "package main\nfunc Outer() {}",
+				"project/.gogobuild":     "root = true",
+				"project/.gogo/inner.go": "package main\nfunc Inner() {}",
+			},
+			startDir: "project",
+			expected: []string{"project/.gogo/inner.go"},
+		},
+		{
+			name: "source points to a folder elsewhere in the repo",
+			files: map[string]string{
+				"tools/gadgets/tasks.go":  "package main\nfunc Task() {}",
+				"svc/.gogobuild":          `source = "../tools/gadgets"`,
+				"svc/.git/HEAD":           "",
+				"svc/cmd/placeholder.txt": "",
+			},
+			startDir: "svc/cmd",
+			expected: []string{"tools/gadgets/tasks.go"},
+		},
+		{
+			name: "several sources",
+			files: map[string]string{
+				"tools/a/a.go": "package main\nfunc A() {}",
+				"ci/b/b.go":    "package main\nfunc B() {}",
+				".gogobuild":   `sources = ["tools/a", "ci/b"]`,
+			},
+			startDir: "",
+			expected: []string{"tools/a/a.go", "ci/b/b.go"},
+		},
+		{
+			name: "missing source folder",
+			files: map[string]string{
+				".gogobuild": `source = "does-not-exist"`,
+			},
+			startDir: "",
+			err:      true,
+		},
+		{
+			name: "invalid config",
+			files: map[string]string{
+				".gogobuild": `root = `,
+			},
+			startDir: "",
+			err:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			// stop the search from leaving the temp dir
+			require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0755))
+			writeTree(t, root, tt.files)
+
+			files, err := findLocalFiles(filepath.Join(root, tt.startDir), gogoFolders)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			var expected []string
+			for _, f := range tt.expected {
+				expected = append(expected, filepath.Join(root, f))
+			}
+			assert.Equal(t, expected, files)
+		})
+	}
+}
+
+func TestFindLocalFuncWithSeveralSources(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0755))
+	writeTree(t, root, map[string]string{
+		"tools/a/a.go": "package main\nfunc A() {}",
+		"ci/b/b.go":    "package main\nfunc B() {}",
+		".gogobuild":   `sources = ["tools/a", "ci/b"]`,
+	})
+
+	source, found, err := findLocalFunc(root, "B", gogoTags, gogoFolders)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, filepath.Join(root, "ci/b"), source.Dir)
+	assert.Equal(t, []string{filepath.Join(root, "ci/b/b.go")}, source.Files)
+}
+
+func TestConfiguredSourcesStopAtGitRoot(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0755))
+	writeTree(t, root, map[string]string{
+		".gogo/outer.go":          "package main\nfunc Outer() {}",
+		"sub/.gogobuild":          `source = "tools"`,
+		"sub/tools/inner.go":      "package main\nfunc Inner() {}",
+		"sub/.git":                "gitdir: ../.git/modules/sub",
+		"sub/cmd/placeholder.txt": "",
+	})
+
+	// the submodule's .git is the boundary of the layers, even though its .gogobuild lists the sources
+	layers, err := localLayers(RunOpts{Layered: true}, filepath.Join(root, "sub", "cmd"))
+	require.NoError(t, err)
+	require.Len(t, layers, 1)
+	assert.Equal(t, filepath.Join(root, "sub"), layers[0].Dir)
+	assert.Equal(t, filepath.Join(root, "sub", "tools"), layers[0].Sources[0].Dir)
+}
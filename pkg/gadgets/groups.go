@@ -0,0 +1,266 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/mod/modfile"
+)
+
+const GROUP_SEPARATOR = ":" // separates the command group from the function, e.g. `docker:Build`
+
+// helperFolders are subpackages of a gogo folder that hold shared code, and never become command groups
+var helperFolders = []string{"internal", "pkg", "testdata", "vendor"}
+
+// QualifiedName returns the name used to run the function, which includes its command group.
+// e.g. `Build` in `.gogo/docker` is run as `docker:Build`
+func (f function) QualifiedName() string {
+	if f.Group == "" {
+		return f.Name
+	}
+	return f.Group + GROUP_SEPARATOR + f.Name
+}
+
+// hasAlias reports whether the function is run with the alias. Like the aliases of mage, an alias runs the
+// function without its group, and it can also be used within the group, like `docker:b`.
+func (f function) hasAlias(name string) bool {
+	for _, alias := range f.Aliases {
+		if name == alias || f.Group != "" && name == f.Group+GROUP_SEPARATOR+alias {
+			return true
+		}
+	}
+	return false
+}
+
+// qualifyNames returns a copy of the functions, named by their QualifiedName
+func qualifyNames(funcs []function) []function {
+	qualified := make([]function, 0, len(funcs))
+	for _, f := range funcs {
+		f.Name = f.QualifiedName()
+		qualified = append(qualified, f)
+	}
+	return qualified
+}
+
+// splitGroup splits a qualified function name into its command group and function name
+func splitGroup(qualifiedName string) (string, string) {
+	i := strings.LastIndex(qualifiedName, GROUP_SEPARATOR)
+	if i < 0 {
+		return "", qualifiedName
+	}
+	return qualifiedName[:i], qualifiedName[i+1:]
+}
+
+// isGroupFolder determines if a subdirectory of a gogo folder is a command group.
+// Like the go tool, folders starting with a dot or underscore are ignored.
+func isGroupFolder(name string) bool {
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+		return false
+	}
+	return !slices.Contains(helperFolders, name)
+}
+
+// groupDir returns the directory of the command group inside the gogo folder
+func groupDir(dir, group string) (string, bool) {
+	parts := strings.Split(group, GROUP_SEPARATOR)
+	for _, part := range parts {
+		if !isGroupFolder(part) {
+			return "", false
+		}
+	}
+	return filepath.Join(append([]string{dir}, parts...)...), true
+}
+
+// findGroupDirs recursively searches the gogo folder for subpackages that become command groups
+func findGroupDirs(dir string) ([]string, error) {
+	items, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, item := range items {
+		if !item.IsDir() || !isGroupFolder(item.Name()) {
+			continue
+		}
+		sub := filepath.Join(dir, item.Name())
+		dirs = append(dirs, sub)
+		nested, err := findGroupDirs(sub)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, nested...)
+	}
+	return dirs, nil
+}
+
+// parseGroups parses the functions of every command group in the gogo folder, with the parse function
+func parseGroups(dir string, parse func(files []string) ([]function, error)) ([]function, error) {
+	dirs, err := findGroupDirs(dir)
+	if err != nil {
+		return nil, err
+	}
+	var functions []function
+	for _, sub := range dirs {
+		files, err := directoryFiles(sub)
+		if err != nil {
+			return nil, err
+		}
+		funcs, err := parse(files)
+		if err != nil {
+			return nil, err
+		}
+		group := groupName(dir, sub)
+		for i := range funcs {
+			funcs[i].Package = group
+			// a mage namespace in the subpackage is nested within the subpackage's group
+			funcs[i].Group = strings.Trim(group+GROUP_SEPARATOR+funcs[i].Group, GROUP_SEPARATOR)
+		}
+		functions = append(functions, funcs...)
+	}
+	return functions, nil
+}
+
+// groupName converts the path of the subpackage inside the gogo folder into the command group name
+func groupName(dir, sub string) string {
+	rel, err := filepath.Rel(dir, sub)
+	if err != nil {
+		return filepath.Base(sub)
+	}
+	return strings.ReplaceAll(filepath.ToSlash(rel), "/", GROUP_SEPARATOR)
+}
+
+// groupAlias returns the name the generated main file imports the command group's package as. Each group has
+// its own alias: lowercase letters and digits are kept, the first letter of each nested group is capitalized,
+// like dockerCompose for docker:compose, and anything else is escaped with its code point between underscores,
+// so my-tools is my_2d_tools, and isn't the same as mytools.
+func groupAlias(group string) string {
+	var alias strings.Builder
+	escape := func(r rune) {
+		alias.WriteString("_" + strconv.FormatInt(int64(r), 16) + "_")
+	}
+	for i, part := range strings.Split(group, GROUP_SEPARATOR) {
+		for j, r := range part {
+			switch {
+			case j == 0 && i > 0 && r >= 'a' && r <= 'z':
+				alias.WriteRune(unicode.ToUpper(r))
+			case j == 0 && i > 0:
+				// the separator can only be left out before a letter that's capitalized
+				escape([]rune(GROUP_SEPARATOR)[0])
+				fallthrough
+			default:
+				if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' && alias.Len() > 0 {
+					alias.WriteRune(r)
+				} else {
+					escape(r)
+				}
+			}
+		}
+	}
+	return alias.String() + "Gadgets"
+}
+
+// groupImportPath returns the import path of the command group's package, based on the go.mod
+// file of the module the gogo folder belongs to.
+func groupImportPath(dir, group string) (string, error) {
+	modRoot, modPath, err := findModule(dir)
+	if err != nil {
+		return "", err
+	}
+	sub, _ := groupDir(dir, group)
+	rel, err := filepath.Rel(modRoot, sub)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(modPath, filepath.ToSlash(rel)), nil
+}
+
+// findModule walks up from the directory to the nearest go.mod file, and returns its directory and module path
+func findModule(dir string) (string, string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			modPath := modfile.ModulePath(data)
+			if modPath == "" {
+				return "", "", fmt.Errorf("no module path found in %s", filepath.Join(dir, "go.mod"))
+			}
+			return dir, modPath, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", fmt.Errorf("no go.mod found for %s", dir)
+		}
+		dir = parent
+	}
+}
+
+// groupImports returns the imports the generated main file needs for the command groups of the functions
+func groupImports(dir string, funcs []function) ([]GoImport, error) {
+	var imports []GoImport
+	for _, f := range funcs {
+		if f.Package == "" {
+			continue
+		}
+		alias := groupAlias(f.Package)
+		if slices.ContainsFunc(imports, func(i GoImport) bool { return i.Alias == alias }) {
+			continue
+		}
+		importPath, err := groupImportPath(dir, f.Package)
+		if err != nil {
+			return nil, err
+		}
+		imports = append(imports, GoImport{Alias: alias, Path: importPath})
+	}
+	return imports, nil
+}
+
+// addToGroup adds the command to the nested group commands, creating the groups as needed. The description,
+// when there is one, describes the innermost group. A function and a group with the same name share a command,
+// which runs the function, unless it's given one of the group's commands.
+func addToGroup(cmds []GoCmd, groups []string, description string, cmd GoCmd) []GoCmd {
+	if len(groups) == 0 {
+		i := slices.IndexFunc(cmds, func(c GoCmd) bool { return !c.Function && cmd.hasName(c.Name) })
+		if i < 0 {
+			return append(cmds, cmd)
+		}
+		cmd.Commands = cmds[i].Commands
+		cmds[i] = cmd
+		return cmds
+	}
+	i := slices.IndexFunc(cmds, func(c GoCmd) bool {
+		return c.hasName(groups[0]) && (len(c.Commands) > 0 || c.Function)
+	})
+	if i < 0 {
+		cmds = append(cmds, GoCmd{Name: groups[0], Short: fmt.Sprintf("%s commands", groups[0])})
+		i = len(cmds) - 1
+	}
+	if len(groups) == 1 && description != "" && !cmds[i].Function {
+		cmds[i].Short = cleanup(description)
+	}
+	cmds[i].Commands = addToGroup(cmds[i].Commands, groups[1:], description, cmd)
+	return cmds
+}
+
+// hasName reports whether the command is run with the name, which is its name or one of its aliases
+func (c GoCmd) hasName(name string) bool {
+	return c.Name == name || slices.Contains(c.Aliases, name)
+}
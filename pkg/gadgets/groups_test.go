@@ -0,0 +1,224 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"go/token"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/2bit-software/gogo/pkg/mod"
+	"github.com/2bit-software/gogo/pkg/sh"
+)
+
+func TestSplitGroup(t *testing.T) {
+	tests := []struct {
+		name      string
+		group     string
+		funcName  string
+		qualified string
+	}{
+		{name: "no group", funcName: "Build", qualified: "Build"},
+		{name: "group", group: "docker", funcName: "Build", qualified: "docker:Build"},
+		{name: "nested group", group: "docker:compose", funcName: "Up", qualified: "docker:compose:Up"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.qualified, function{Name: tt.funcName, Group: tt.group}.QualifiedName())
+			group, funcName := splitGroup(tt.qualified)
+			assert.Equal(t, tt.group, group)
+			assert.Equal(t, tt.funcName, funcName)
+		})
+	}
+}
+
+func TestBuildFuncListGrouped(t *testing.T) {
+	root, err := mod.FindModuleRoot()
+	require.NoError(t, err)
+	scenarioDir := path.Join(root, "scenarios", "grouped")
+
+	funcList, err := BuildFuncList(RunOpts{}, scenarioDir)
+	require.NoError(t, err)
+	sortFuncs(funcList)
+	var names []string
+	for _, f := range funcList {
+		names = append(names, f.Name)
+	}
+	// the internal folder holds shared code, and is not a command group
+	// the methods of a task struct are in a group named after it
+	assert.Equal(t, []string{"Cleanup", "Docker", "Hello", "docker:Build", "docker:compose:Up", "docker:image:Push", "my-tools:Lint", "release:Notes", "release:Publish"}, names)
+
+	match, found, err := findFuncInSources([]gadgetSource{{Dir: path.Join(scenarioDir, ".gogo")}}, "docker:compose:Up")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, path.Join(scenarioDir, ".gogo"), match.Source.Dir)
+	assert.Equal(t, "docker:compose", match.Func.Group)
+	_, found, err = findFuncInSources([]gadgetSource{match.Source}, "internal:Describe")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	// the alias of a function in a group runs it with or without the group, like in the binary
+	for _, alias := range []string{"up", "docker:compose:up"} {
+		match, found, err = findFuncInSources([]gadgetSource{match.Source}, alias)
+		require.NoError(t, err)
+		assert.True(t, found, alias)
+		assert.Equal(t, "docker:compose:Up", match.Func.QualifiedName())
+	}
+	_, found, err = findFuncInSources([]gadgetSource{match.Source}, "docker:up")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestBuildGrouped(t *testing.T) {
+	l := log.New(os.Stdout, "", log.LstdFlags)
+	root, err := mod.FindModuleRoot()
+	require.NoError(t, err)
+	tmpDir := t.TempDir()
+
+	opts := BuildOpts{
+		DisableCache:   true,
+		SourceDir:      path.Join(root, "scenarios", "grouped", ".gogo"),
+		BinaryFilepath: path.Join(tmpDir, "gadgets"),
+	}
+	err = Build(l, opts)
+	require.NoError(t, err)
+
+	out, err := sh.Cmd(opts.BinaryFilepath).SetArgs("docker", "Build", "v1").String()
+	require.NoError(t, err)
+	assert.Equal(t, "docker build: v1", strings.TrimSpace(out))
+
+	// the commands are also registered by their kebab-case name
+	out, err = sh.Cmd(opts.BinaryFilepath).SetArgs("docker", "build", "v2").String()
+	require.NoError(t, err)
+	assert.Equal(t, "docker build: v2", strings.TrimSpace(out))
+
+	out, err = sh.Cmd(opts.BinaryFilepath).SetArgs("docker", "compose", "Up").String()
+	require.NoError(t, err)
+	assert.Equal(t, "compose up", strings.TrimSpace(out))
+
+	// the alias of a function in a group runs it with or without the group, like with gogo
+	for _, args := range [][]string{{"up"}, {"docker", "compose", "up"}} {
+		out, err = sh.Cmd(opts.BinaryFilepath).SetArgs(args...).String()
+		require.NoError(t, err)
+		assert.Equal(t, "compose up", strings.TrimSpace(out))
+	}
+
+	// a function with the name of a group is run when none of the group's commands are
+	out, err = sh.Cmd(opts.BinaryFilepath).SetArgs("docker").String()
+	require.NoError(t, err)
+	assert.Equal(t, "docker", strings.TrimSpace(out))
+
+	// a group can be in a folder that isn't a valid go identifier
+	out, err = sh.Cmd(opts.BinaryFilepath).SetArgs("my-tools", "lint").String()
+	require.NoError(t, err)
+	assert.Equal(t, "linted the tools", strings.TrimSpace(out))
+
+	// and by the aliases set with ctx.Alias
+	out, err = sh.Cmd(opts.BinaryFilepath).SetArgs("hi").String()
+	require.NoError(t, err)
+	assert.Equal(t, "Hello from the root", strings.TrimSpace(out))
+
+	// the methods of a task struct are called on a new value of it, and its doc comment describes the group
+	out, err = sh.Cmd(opts.BinaryFilepath).SetArgs("release", "notes", "v1").String()
+	require.NoError(t, err)
+	assert.Equal(t, "notes for v1", strings.TrimSpace(out))
+	out, err = sh.Cmd(opts.BinaryFilepath).SetArgs("release", "publish").String()
+	require.NoError(t, err)
+	assert.Equal(t, "published", strings.TrimSpace(out))
+	out, err = sh.Cmd(opts.BinaryFilepath).SetArgs("docker", "image", "push", "v3").String()
+	require.NoError(t, err)
+	assert.Equal(t, "docker push: v3", strings.TrimSpace(out))
+	out, err = sh.Cmd(opts.BinaryFilepath).SetArgs("--help").String()
+	require.NoError(t, err)
+	assert.Contains(t, out, "Release publishes the binaries.")
+
+	// a hidden function isn't in the help, but can still be run
+	assert.NotContains(t, out, "cleanup")
+	out, err = sh.Cmd(opts.BinaryFilepath).SetArgs("cleanup").String()
+	require.NoError(t, err)
+	assert.Equal(t, "cleaned up", strings.TrimSpace(out))
+}
+
+func TestGroupAlias(t *testing.T) {
+	tests := []struct {
+		group string
+		alias string
+	}{
+		{group: "docker", alias: "dockerGadgets"},
+		{group: "docker:compose", alias: "dockerComposeGadgets"},
+		{group: "my-tools", alias: "my_2d_toolsGadgets"},
+		{group: "docker-compose", alias: "docker_2d_composeGadgets"},
+		{group: "dockercompose", alias: "dockercomposeGadgets"},
+		{group: "docker_compose", alias: "docker_5f_composeGadgets"},
+		{group: "v2:1x", alias: "v2_3a_1xGadgets"},
+		{group: "2d", alias: "_32_dGadgets"},
+	}
+	aliases := map[string]string{}
+	for _, tt := range tests {
+		t.Run(tt.group, func(t *testing.T) {
+			alias := groupAlias(tt.group)
+			assert.Equal(t, tt.alias, alias)
+			assert.True(t, token.IsIdentifier(alias))
+			// each group is imported with its own alias
+			assert.NotContains(t, aliases, alias)
+			aliases[alias] = tt.group
+		})
+	}
+}
+
+func TestAddToGroup(t *testing.T) {
+	build := GoCmd{Name: "Build", Aliases: []string{"build"}, Function: true}
+	docker := GoCmd{Name: "Docker", Aliases: []string{"docker"}, Function: true}
+	grouped := GoCmd{Name: "docker", Short: "docker commands", Commands: []GoCmd{build}}
+
+	// a function and a group with the same name share a command, whichever is added first
+	cmds := addToGroup(nil, nil, "", docker)
+	cmds = addToGroup(cmds, []string{"docker"}, "", build)
+	assert.Equal(t, []GoCmd{{Name: "Docker", Aliases: []string{"docker"}, Function: true, Commands: []GoCmd{build}}}, cmds)
+
+	cmds = addToGroup(nil, []string{"docker"}, "", build)
+	assert.Equal(t, []GoCmd{grouped}, cmds)
+	cmds = addToGroup(cmds, nil, "", docker)
+	assert.Equal(t, []GoCmd{{Name: "Docker", Aliases: []string{"docker"}, Function: true, Commands: []GoCmd{build}}}, cmds)
+
+	// the function describes the command, rather than the group
+	cmds = addToGroup([]GoCmd{docker}, []string{"docker"}, "Docker builds the images", build)
+	assert.Empty(t, cmds[0].Short)
+
+	// other functions are added next to the group
+	cmds = addToGroup([]GoCmd{grouped}, nil, "", build)
+	assert.Equal(t, []GoCmd{grouped, build}, cmds)
+}
+
+func TestGroupImportPath(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		".gogo/docker/docker.go": "package docker\nfunc Build() {}",
+	})
+	_, err := groupImportPath(filepath.Join(root, ".gogo"), "docker")
+	assert.Error(t, err, "there is no go.mod to import the group from")
+
+	// the group is imported from the module the gogo folder is in
+	writeTree(t, root, map[string]string{"go.mod": "module github.com/example/project\n"})
+	importPath, err := groupImportPath(filepath.Join(root, ".gogo"), "docker")
+	require.NoError(t, err)
+	assert.Equal(t, "github.com/example/project/.gogo/docker", importPath)
+
+	// or from the gogo folder's own module
+	writeTree(t, root, map[string]string{".gogo/go.mod": "module github.com/example/gadgets\n"})
+	importPath, err = groupImportPath(filepath.Join(root, ".gogo"), "docker")
+	require.NoError(t, err)
+	assert.Equal(t, "github.com/example/gadgets/docker", importPath)
+}
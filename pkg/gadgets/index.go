@@ -0,0 +1,322 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/2bit-software/gogo/pkg/sh"
+)
+
+// This file keeps an index of the parsed functions on disk, so listing, completing and finding the function
+// to run don't type check the gadgets every time gogo runs. The functions are indexed per file, by the path,
+// size and content hash of the file they're declared in, and only the files that changed are indexed again.
+// The files of a package are type checked together, so each entry also has a fingerprint of what the functions
+// depend on outside their file: the declarations of the other files, the go.mod and go.sum, and the compiled
+// packages the files import. Building a binary always parses the files, so the generated code never comes
+// from the index.
+
+const (
+	INDEX_DIR_ENV = "GOGO_INDEX_DIR" // overrides where the index is kept, and INDEX_OFF disables it
+	INDEX_OFF     = "off"
+	// INDEX_VERSION is increased when the function or argument structs change, or they're parsed differently,
+	// so the entries written by an older gogo are parsed again
+	INDEX_VERSION = 5
+)
+
+// indexEntry is the functions declared in a file, along with what they were parsed from
+type indexEntry struct {
+	Version int
+	File    indexedFile // the file, as it was when it was parsed
+	Deps    string      // the fingerprint of what the functions depend on outside the file, when it was parsed
+	Funcs   []function
+}
+
+// indexedFile is a file of the package, as it was when the package was parsed
+type indexedFile struct {
+	Path string
+	Size int64
+	Hash string
+}
+
+// indexDir returns the folder the index is kept in, or "" when the index is disabled
+func indexDir() string {
+	if dir, found := os.LookupEnv(INDEX_DIR_ENV); found {
+		if dir == INDEX_OFF {
+			return ""
+		}
+		return dir
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cacheDir, "gogo", "index")
+}
+
+// parseIndexed returns the functions of the files, like parseAll, reading the functions of each file from the
+// index when neither the file nor its dependencies changed since it was parsed. When one of them did, the package
+// is parsed again, and the entries of the files that changed are replaced. The index is only a cache, so failing
+// to read or write it isn't an error.
+func parseIndexed(files []string) ([]function, error) {
+	dir := indexDir()
+	if dir == "" || len(files) == 0 {
+		return parseAll(files)
+	}
+	current, err := indexFiles(files)
+	if err != nil {
+		return parseAll(files)
+	}
+	decls, err := readDeclarations(files)
+	if err != nil {
+		return parseAll(files)
+	}
+	deps := dependencyFingerprint(files, decls)
+
+	var funcs []function
+	var stale []int
+	for i, file := range current {
+		entry, found := readIndexEntry(filepath.Join(dir, indexKey(file.Path)+".json"))
+		if found && entry.File == file && entry.Deps == deps {
+			funcs = append(funcs, entry.Funcs...)
+			continue
+		}
+		stale = append(stale, i)
+	}
+	if len(stale) == 0 {
+		return funcs, nil
+	}
+
+	parsed, err := parseAll(files)
+	if err != nil {
+		return nil, err
+	}
+	byFile, found := splitByFile(parsed, decls)
+	if !found {
+		return parsed, nil
+	}
+	for _, i := range stale {
+		entry := indexEntry{Version: INDEX_VERSION, File: current[i], Deps: deps, Funcs: byFile[i]}
+		_ = writeIndexEntry(filepath.Join(dir, indexKey(current[i].Path)+".json"), entry)
+	}
+	// the functions are in the order of their files, like the ones read from the index
+	funcs = nil
+	for _, fileFuncs := range byFile {
+		funcs = append(funcs, fileFuncs...)
+	}
+	return funcs, nil
+}
+
+// indexKey names the entry of the file, from its absolute path
+func indexKey(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	sum := sha256.Sum256([]byte(file))
+	return hex.EncodeToString(sum[:])
+}
+
+// indexFiles returns the size and content hash of each file, in the order of the files
+func indexFiles(files []string) ([]indexedFile, error) {
+	indexed := make([]indexedFile, 0, len(files))
+	for _, file := range files {
+		hash, size, err := hashFile(file)
+		if err != nil {
+			return nil, err
+		}
+		indexed = append(indexed, indexedFile{Path: file, Size: size, Hash: hash})
+	}
+	return indexed, nil
+}
+
+// declarations is what the functions of the other files of the package can depend on in a file
+type declarations struct {
+	funcs   []string // the functions and methods declared in the file, as `Receiver.Name`
+	imports []string // the import paths of the file
+	hash    string   // the hash of the file without the bodies of its functions, which only affect their own function
+}
+
+// readDeclarations parses the declarations of each file, without type checking them
+func readDeclarations(files []string) ([]declarations, error) {
+	fset := token.NewFileSet()
+	decls := make([]declarations, 0, len(files))
+	for _, name := range files {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		file, err := parser.ParseFile(fset, name, src, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		var fileDecls declarations
+		h := sha256.New()
+		for _, imp := range file.Imports {
+			if path, err := strconv.Unquote(imp.Path.Value); err == nil {
+				fileDecls.imports = append(fileDecls.imports, path)
+			}
+		}
+		for _, decl := range file.Decls {
+			start, end := decl.Pos(), decl.End()
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				// the doc comment of a type describes its command group
+				if decl.Doc != nil {
+					start = decl.Doc.Pos()
+				}
+			case *ast.FuncDecl:
+				fileDecls.funcs = append(fileDecls.funcs, funcKey(receiverName(decl), decl.Name.Name))
+				if decl.Body != nil {
+					end = decl.Body.Pos()
+				}
+			}
+			h.Write(src[fset.Position(start).Offset:fset.Position(end).Offset])
+		}
+		fileDecls.hash = hex.EncodeToString(h.Sum(nil))
+		decls = append(decls, fileDecls)
+	}
+	return decls, nil
+}
+
+// receiverName returns the name of the type the function is a method of, or "" when it isn't a method
+func receiverName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) != 1 {
+		return ""
+	}
+	recv := decl.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	if ident, ok := recv.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+func funcKey(receiver, name string) string {
+	return receiver + "." + name
+}
+
+// splitByFile returns the functions declared in each file, or false when one of them isn't found in any file
+func splitByFile(funcs []function, decls []declarations) ([][]function, bool) {
+	byFile := make([][]function, len(decls))
+	for _, f := range funcs {
+		i := slices.IndexFunc(decls, func(d declarations) bool {
+			return slices.Contains(d.funcs, funcKey(f.Receiver, f.Name))
+		})
+		if i == -1 {
+			return nil, false
+		}
+		byFile[i] = append(byFile[i], f)
+	}
+	return byFile, true
+}
+
+// dependencyFingerprint hashes what the functions of a file depend on outside of it: the declarations of the
+// files of the package, the go.mod and go.sum of their module, and the compiled packages they import.
+// A dependency that can't be read is part of the fingerprint as missing, so it's indexed again once it can be.
+func dependencyFingerprint(files []string, decls []declarations) string {
+	h := sha256.New()
+	var imports []string
+	for _, d := range decls {
+		fmt.Fprintln(h, "decls", d.hash)
+		for _, imp := range d.imports {
+			if !slices.Contains(imports, imp) && imp != "C" {
+				imports = append(imports, imp)
+			}
+		}
+	}
+	if modRoot, _, err := findModule(filepath.Dir(files[0])); err == nil {
+		for _, name := range []string{"go.mod", "go.sum"} {
+			hash, _, _ := hashFile(filepath.Join(modRoot, name))
+			fmt.Fprintln(h, name, hash)
+		}
+	}
+	if len(imports) == 0 {
+		return hex.EncodeToString(h.Sum(nil))
+	}
+	// the export data of a package changes with the package and with the packages it exposes types from
+	slices.Sort(imports)
+	cmd := append([]string{"go", "list", "-e", "-export", "-tags=gogo,mage", "-mod=readonly",
+		"-f", "{{.ImportPath}} {{.Export}}"}, imports...)
+	out, _ := sh.Cmd(cmd...).Dir(filepath.Dir(files[0])).String()
+	for _, line := range strings.Split(out, "\n") {
+		path, export, _ := strings.Cut(line, " ")
+		hash := ""
+		if export != "" {
+			hash, _, _ = hashFile(export)
+		}
+		fmt.Fprintln(h, "import", path, hash)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hashFile returns the SHA-256 of the file's content, and its size
+func hashFile(file string) (string, int64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// readIndexEntry reads the entry, which isn't found when it's missing, unreadable or from another INDEX_VERSION
+func readIndexEntry(entryPath string) (indexEntry, bool) {
+	data, err := os.ReadFile(entryPath)
+	if err != nil {
+		return indexEntry{}, false
+	}
+	var entry indexEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Version != INDEX_VERSION {
+		return indexEntry{}, false
+	}
+	return entry, true
+}
+
+// writeIndexEntry writes the entry to a temporary file first, so a gogo running at the same time never reads
+// half of it
+func writeIndexEntry(entryPath string, entry indexEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(entryPath), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(entryPath), filepath.Base(entryPath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), entryPath)
+}
@@ -0,0 +1,193 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/2bit-software/gogo/pkg/mod"
+)
+
+// TestMain keeps the index of the tests out of the user's cache folder
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "gogo-index")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv(INDEX_DIR_ENV, dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestParseIndexed(t *testing.T) {
+	index := t.TempDir()
+	t.Setenv(INDEX_DIR_ENV, index)
+	dir := t.TempDir()
+	write := func(name, src string) string {
+		file := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(file, []byte(src), 0o644))
+		return file
+	}
+	write("go.mod", "module gadgets\n\ngo 1.23\n")
+	build := write("build.go", "package main\n\n// Build builds it\nfunc Build(name string) {}\n")
+	deploy := write("deploy.go", "package main\n\nfunc Deploy() {}\n")
+	files := []string{build, deploy}
+	buildEntry := filepath.Join(index, indexKey(build)+".json")
+	deployEntry := filepath.Join(index, indexKey(deploy)+".json")
+
+	funcs, err := parseIndexed(files)
+	require.NoError(t, err)
+	require.Len(t, funcs, 2)
+	require.FileExists(t, buildEntry)
+	require.FileExists(t, deployEntry)
+
+	// the functions are read from the index while the files are the same, so a changed entry is what's returned
+	entry, found := readIndexEntry(buildEntry)
+	require.True(t, found)
+	require.Len(t, entry.Funcs, 1)
+	entry.Funcs[0].Description = "from the index"
+	require.NoError(t, writeIndexEntry(buildEntry, entry))
+	funcs, err = parseIndexed(files)
+	require.NoError(t, err)
+	assert.Equal(t, "from the index", funcs[0].Description)
+
+	// changing the body of a function only indexes its file again
+	write("deploy.go", "package main\n\nfunc Deploy() { println() }\n")
+	funcs, err = parseIndexed(files)
+	require.NoError(t, err)
+	require.Len(t, funcs, 2)
+	entry, found = readIndexEntry(buildEntry)
+	require.True(t, found)
+	assert.Equal(t, "from the index", entry.Funcs[0].Description)
+	funcs, err = parseIndexed(files)
+	require.NoError(t, err)
+	assert.Equal(t, "from the index", funcs[0].Description)
+
+	// while changing a declaration indexes the other files of the package again, since they can depend on it
+	write("deploy.go", "package main\n\nfunc Deploy(env string) {}\n")
+	funcs, err = parseIndexed(files)
+	require.NoError(t, err)
+	require.Len(t, funcs, 2)
+	assert.Equal(t, "Build builds it", funcs[0].Description)
+	assert.Equal(t, []argument{{Name: "env", Type: "string"}}, funcs[1].Arguments)
+
+	// and so does changing the go.mod
+	entry, found = readIndexEntry(buildEntry)
+	require.True(t, found)
+	write("go.mod", "module gadgets\n\ngo 1.23.0\n")
+	_, err = parseIndexed(files)
+	require.NoError(t, err)
+	updated, found := readIndexEntry(buildEntry)
+	require.True(t, found)
+	assert.NotEqual(t, entry.Deps, updated.Deps)
+
+	// an entry from another version of the index is ignored
+	entry.Version = INDEX_VERSION + 1
+	entry.Funcs = nil
+	data, err := json.Marshal(entry)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(buildEntry, data, 0o644))
+	funcs, err = parseIndexed(files)
+	require.NoError(t, err)
+	assert.Len(t, funcs, 2)
+
+	// errors aren't indexed
+	broken := write("broken.go", "package main\n\nfunc Broken( {}\n")
+	_, err = parseIndexed([]string{broken})
+	require.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(index, indexKey(broken)+".json"))
+
+	// the index can be turned off
+	t.Setenv(INDEX_DIR_ENV, INDEX_OFF)
+	assert.Empty(t, indexDir())
+	funcs, err = parseIndexed(files)
+	require.NoError(t, err)
+	assert.Len(t, funcs, 2)
+}
+
+// Test that the defaults and allowed values read from the index are the strings they're parsed as
+func TestParseIndexedValues(t *testing.T) {
+	t.Setenv(INDEX_DIR_ENV, t.TempDir())
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module gadgets\n\ngo 1.23\n"), 0o644))
+	file := filepath.Join(dir, "scale.go")
+	src := fmt.Sprintf(`package main
+
+import "%s"
+
+func Scale(ctx gogo.Context, replicas int) {
+	ctx.Argument(replicas).Default(3).AllowedValues(1, 3, 5)
+}
+`, GOGOIMPORTPATH)
+	require.NoError(t, os.WriteFile(file, []byte(src), 0o644))
+
+	parsed, err := parseIndexed([]string{file})
+	require.NoError(t, err)
+	indexed, err := parseIndexed([]string{file})
+	require.NoError(t, err)
+	assert.Equal(t, parsed, indexed)
+	require.Len(t, indexed, 1)
+	assert.Equal(t, "3", indexed[0].Arguments[0].Default)
+	assert.Equal(t, []string{"1", "3", "5"}, indexed[0].Arguments[0].AllowedValues)
+}
+
+// Test that a change to a package the files import indexes them again
+func TestParseIndexedImports(t *testing.T) {
+	index := t.TempDir()
+	t.Setenv(INDEX_DIR_ENV, index)
+	dir := t.TempDir()
+	write := func(name, src string) string {
+		file := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		require.NoError(t, os.WriteFile(file, []byte(src), 0o644))
+		return file
+	}
+	write("go.mod", "module gadgets\n\ngo 1.23\n")
+	write("lib/lib.go", "package lib\n\ntype Env = string\n")
+	build := write("build.go", "package main\n\nimport \"gadgets/lib\"\n\nfunc Build(env lib.Env) {}\n")
+
+	funcs, err := parseIndexed([]string{build})
+	require.NoError(t, err)
+	require.Len(t, funcs, 1)
+	assert.Equal(t, "string", funcs[0].Arguments[0].Type)
+
+	write("lib/lib.go", "package lib\n\ntype Env = int\n")
+	funcs, err = parseIndexed([]string{build})
+	require.NoError(t, err)
+	require.Len(t, funcs, 1)
+	assert.Equal(t, "int", funcs[0].Arguments[0].Type)
+}
+
+func TestParseIndexedScenarios(t *testing.T) {
+	t.Setenv(INDEX_DIR_ENV, t.TempDir())
+	root, err := mod.FindModuleRoot()
+	require.NoError(t, err)
+	// the functions read from the index are the same as the ones parsed, including their context options
+	for _, scenario := range []string{"standard", "types", "aliased", "grouped"} {
+		t.Run(scenario, func(t *testing.T) {
+			files, err := directoryFiles(path.Join(root, "scenarios", scenario, ".gogo"))
+			require.NoError(t, err)
+			parsed, err := parseAll(files)
+			require.NoError(t, err)
+			_, err = parseIndexed(files)
+			require.NoError(t, err)
+			indexed, err := parseIndexed(files)
+			require.NoError(t, err)
+			assert.Equal(t, parsed, indexed)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// This file explains why exported functions aren't gadgets. The parser skips the functions it can't run, like
+// one with a pointer argument, and the invalid uses of the context, so `gogo lint` prints the reasons it
+// recorded for them, and the Analyzer reports them to go vet and editors.
+
+// The kinds of problems a diagnostic can describe
+const (
+	CATEGORY_SIGNATURE = "signature" // the function's arguments or return types can't be run, so it isn't a gadget
+	CATEGORY_CONTEXT   = "context"   // a call on the context is invalid, and is ignored
+	CATEGORY_CONFLICT  = "conflict"  // the gadgets can't be built, like when a property is set to two different values
+	CATEGORY_SYNTAX    = "syntax"    // the file isn't valid go
+	CATEGORY_TYPE      = "type"      // the file doesn't type check, so the types of some expressions aren't known
+	CATEGORY_METHOD    = "method"    // an exported method isn't a gadget, because its struct isn't a command group
+)
+
+// diagnostic is a problem with the gadgets, at the part of them that's wrong
+type diagnostic struct {
+	Pos      token.Pos      // where the problem is, or NoPos for a syntax error, which is only known by its Position
+	Position token.Position // where the problem is, to print it
+	Function string         // the function the problem is with, if it's with one
+	Category string
+	Message  string
+}
+
+// describe explains the problem, without its position
+func (d diagnostic) describe() string {
+	if d.Function == "" {
+		return d.Message
+	}
+	return fmt.Sprintf("%s: %s", d.Function, d.Message)
+}
+
+func (d diagnostic) String() string {
+	return fmt.Sprintf("%s: %s", d.Position, d.describe())
+}
+
+// diagnostics collects the problems found in the files of a package
+type diagnostics struct {
+	fset *token.FileSet
+	list []diagnostic
+}
+
+func (d *diagnostics) report(pos token.Pos, function, category, message string) {
+	d.list = append(d.list, diagnostic{
+		Pos:      pos,
+		Position: d.fset.Position(pos),
+		Function: function,
+		Category: category,
+		Message:  message,
+	})
+}
+
+// Lint writes the problems with the local gogo files, like the exported functions that aren't gadgets and why,
+// returning how many there are. It lints the same sources the build does.
+func Lint(opts RunOpts, w io.Writer) (int, error) {
+	sources, err := buildRequestedDir(opts)
+	if err != nil {
+		return 0, err
+	}
+	if len(sources) == 0 {
+		return 0, fmt.Errorf("no gogo files found")
+	}
+	count := 0
+	for _, source := range sources {
+		diags, err := source.lint()
+		if err != nil {
+			return count, err
+		}
+		for _, diag := range diags {
+			diag.Position.Filename = relativePath(opts.OriginalWorkingDir, diag.Position.Filename)
+			if _, err := fmt.Fprintln(w, diag); err != nil {
+				return count, err
+			}
+		}
+		count += len(diags)
+	}
+	return count, nil
+}
+
+// lint returns the problems with the files of the source, and of its command groups
+func (s gadgetSource) lint() ([]diagnostic, error) {
+	diags, err := lintFiles(s.Files)
+	if err != nil || s.Tagged {
+		return diags, err
+	}
+	dirs, err := findGroupDirs(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		files, err := directoryFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		groupDiags, err := lintFiles(files)
+		if err != nil {
+			return nil, err
+		}
+		diags = append(diags, groupDiags...)
+	}
+	return diags, nil
+}
+
+// lintFiles returns the problems with the files of a single package. An error that stops the package from
+// being built, like an alias used by two functions, is one of them.
+func lintFiles(files []string) ([]diagnostic, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	checked, err := loadPackage(files)
+	if err != nil {
+		return nil, err
+	}
+	_, diags, err := analyzePackage(checked)
+	// the conflicts are already diagnostics, at the position of the conflicting call
+	var conflict *conflictError
+	if err != nil && !errors.As(err, &conflict) {
+		diags = append(diags, diagnostic{
+			Position: token.Position{Filename: filepath.Dir(files[0])},
+			Category: CATEGORY_CONFLICT,
+			Message:  err.Error(),
+		})
+	}
+	return diags, nil
+}
+
+// relativePath returns the path relative to the directory, when it's inside it
+func relativePath(dir, path string) string {
+	if dir == "" {
+		return path
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
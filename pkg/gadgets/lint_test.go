@@ -0,0 +1,120 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzePackage(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected []string
+	}{
+		{
+			name:     "unsupported parameter",
+			body:     `func Deploy(ctx gogo.Context, env string, cfg *Config) {}`,
+			expected: []string{"build.go:5:43: Deploy: parameter 3 has unsupported type *Config"},
+		},
+		{
+			name:     "gogo context not first",
+			body:     `func Deploy(env string, ctx gogo.Context) {}`,
+			expected: []string{"build.go:5:25: Deploy: gogo.Context must be the first parameter"},
+		},
+		{
+			name:     "std context not first",
+			body:     `func Deploy(env string, ctx context.Context) {}`,
+			expected: []string{"build.go:5:25: Deploy: context.Context must be the first parameter"},
+		},
+		{
+			name:     "too many return values",
+			body:     `func Deploy() (string, int, error) { return "", 0, nil }`,
+			expected: []string{"build.go:5:15: Deploy: returns 3 values, but a gadget can only return a value and an error"},
+		},
+		{
+			name:     "second return value not an error",
+			body:     `func Deploy() (string, int) { return "", 0 }`,
+			expected: []string{"build.go:5:24: Deploy: the second return value has type int, but only an error can be returned after a value"},
+		},
+		{
+			name:     "return type not written",
+			body:     `func Deploy() func() { return nil }`,
+			expected: []string{"build.go:5:15: Deploy: return type func() can't be written as output"},
+		},
+		{
+			name:     "options struct with other parameters",
+			body:     `func Deploy(env string, cfg Config) {}`,
+			expected: []string{"build.go:5:25: Deploy: options struct Config has to be the only parameter besides the context"},
+		},
+		{
+			name: "conflicting property",
+			body: `func Deploy(ctx gogo.Context) {
+	ctx.ShortDescription("Deploy it")
+	ctx.ShortDescription("Deploy everything")
+}`,
+			expected: []string{`build.go:7:6: Deploy: ShortDescription of Deploy is set to "Deploy everything", but it is already set to "Deploy it" at build.go:6:6`},
+		},
+		{
+			name: "unexported functions and methods are skipped",
+			body: `func deploy(cfg *Config) {}
+type local struct{}
+func (local) Deploy(cfg *Config) {}
+func Build(ctx gogo.Context, env string) error { return nil }`,
+		},
+		{
+			name:     "type error",
+			body:     `func Deploy(env string) error { return env }`,
+			expected: []string{"build.go:5:40: cannot use env (variable of type string) as error value in return statement: string does not implement error (missing method Error)"},
+		},
+		{
+			name: "syntax error",
+			body: `func Deploy( {}`,
+			// the parser carries on after the error, so it can report more than one
+			expected: []string{"build.go:5:14: expected ')', found '{'", "build.go:6:1: expected ')', found 'var'", "build.go:7:1: expected ')', found 'var'"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := fmt.Sprintf("package gogo\nimport \"context\"\nimport \"%s\"\ntype Config struct{ Env string }\n%s\nvar _ context.Context\nvar _ gogo.Context", GOGOIMPORTPATH, tt.body)
+			_, diags, _ := analyzePackage(checkSources([]string{"build.go"}, []string{src}))
+			var got []string
+			for _, diag := range diags {
+				got = append(got, diag.String())
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestLint(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, ".gogo")
+	require.NoError(t, os.MkdirAll(filepath.Join(source, "docker"), 0o755))
+	write := func(name, src string) {
+		require.NoError(t, os.WriteFile(filepath.Join(source, name), []byte(src), 0o644))
+	}
+	write("go.mod", "module gadgets\n\ngo 1.23\n")
+	write("build.go", "package main\n\nimport \"context\"\n\nfunc Build(ctx context.Context) {}\n\nfunc Deploy(env string, ctx context.Context) {}\n")
+	write(filepath.Join("docker", "docker.go"), "package docker\n\nfunc Push(tags map[string]string) {}\n")
+
+	var out bytes.Buffer
+	count, err := Lint(RunOpts{BuildOpts: BuildOpts{SourceDir: source, OriginalWorkingDir: dir}}, &out)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, fmt.Sprintf(`%s:7:25: Deploy: context.Context must be the first parameter
+%s:3:11: Push: parameter 1 has unsupported type map[string]string
+`, filepath.Join(".gogo", "build.go"), filepath.Join(".gogo", "docker", "docker.go")), out.String())
+}
@@ -0,0 +1,127 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const maxSuggestions = 3 // the most "did you mean" suggestions shown when a function is not found
+
+// kebabCase converts an exported go name into kebab-case, e.g. `BuildHTTPServer` becomes `build-http-server`.
+// Each part of a qualified name is converted separately, so `docker:BuildImage` becomes `docker:build-image`.
+func kebabCase(name string) string {
+	parts := strings.Split(name, GROUP_SEPARATOR)
+	for i, part := range parts {
+		parts[i] = kebabPart(part)
+	}
+	return strings.Join(parts, GROUP_SEPARATOR)
+}
+
+func kebabPart(name string) string {
+	runes := []rune(name)
+	var out strings.Builder
+	for i, r := range runes {
+		if r == '_' {
+			out.WriteRune('-')
+			continue
+		}
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			// start a new word after a lower case letter or digit, or at the end of an acronym
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				out.WriteRune('-')
+			}
+		}
+		out.WriteRune(unicode.ToLower(r))
+	}
+	return out.String()
+}
+
+// normalizeName reduces a name to the form used to compare names, ignoring case, dashes and underscores.
+// This lets `build-docker`, `build_docker` and `builddocker` all find `BuildDocker`.
+func normalizeName(name string) string {
+	name = strings.ToLower(name)
+	return strings.NewReplacer("-", "", "_", "").Replace(name)
+}
+
+// namesMatch determines if the name the user typed refers to the function name
+func namesMatch(funcName, typed string) bool {
+	return normalizeName(funcName) == normalizeName(typed)
+}
+
+// commandAliases returns the other names a command is registered under in the generated binary,
+// so the binary accepts the same forms of the name as gogo does.
+func commandAliases(name string) []string {
+	var aliases []string
+	kebab := kebabCase(name)
+	snake := strings.ReplaceAll(kebab, "-", "_")
+	for _, alias := range []string{kebab, snake, strings.ToLower(name)} {
+		if alias == name || slices.Contains(aliases, alias) {
+			continue
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases
+}
+
+// suggestNames returns the names closest to what the user typed, by edit distance,
+// which are close enough to be a likely typo.
+func suggestNames(names []string, typed string) []string {
+	target := normalizeName(typed)
+	maxDistance := max(2, len(target)/3)
+	type suggestion struct {
+		name     string
+		distance int
+	}
+	var suggestions []suggestion
+	for _, name := range names {
+		distance := levenshtein(normalizeName(name), target)
+		if distance <= maxDistance {
+			suggestions = append(suggestions, suggestion{name: name, distance: distance})
+		}
+	}
+	slices.SortStableFunc(suggestions, func(a, b suggestion) int {
+		return a.distance - b.distance
+	})
+	var out []string
+	for _, s := range suggestions {
+		if len(out) == maxSuggestions {
+			break
+		}
+		if !slices.Contains(out, s.name) {
+			out = append(out, s.name)
+		}
+	}
+	return out
+}
+
+// levenshtein returns the edit distance between the two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
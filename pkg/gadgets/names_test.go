@@ -0,0 +1,119 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKebabCase(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "Build", want: "build"},
+		{name: "BuildDocker", want: "build-docker"},
+		{name: "BuildHTTPServer", want: "build-http-server"},
+		{name: "HTTPServer", want: "http-server"},
+		{name: "Build2Docker", want: "build2-docker"},
+		{name: "build_docker", want: "build-docker"},
+		{name: "docker:BuildImage", want: "docker:build-image"},
+		{name: "g:RunTests", want: "g:run-tests"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, kebabCase(tt.name))
+		})
+	}
+}
+
+func TestCommandAliases(t *testing.T) {
+	assert.Nil(t, commandAliases("build"))
+	assert.Equal(t, []string{"build"}, commandAliases("Build"))
+	assert.Equal(t, []string{"build-docker", "build_docker", "builddocker"}, commandAliases("BuildDocker"))
+}
+
+func TestMatchFunc(t *testing.T) {
+	funcs := []function{
+		{Name: "BuildDocker"},
+		{Name: "Build", Aliases: []string{"b"}},
+		{Name: "Up", Group: "docker"},
+		{Name: "Bd"},
+	}
+	tests := []struct {
+		typed string
+		want  string
+		found bool
+	}{
+		{typed: "BuildDocker", want: "BuildDocker", found: true},
+		{typed: "build-docker", want: "BuildDocker", found: true},
+		{typed: "build_docker", want: "BuildDocker", found: true},
+		{typed: "BUILDDOCKER", want: "BuildDocker", found: true},
+		{typed: "b", want: "Build", found: true},
+		{typed: "bd", want: "Bd", found: true},
+		{typed: "Docker:up", want: "docker:Up", found: true},
+		{typed: "up", found: false},
+		{typed: "build-dockr", found: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typed, func(t *testing.T) {
+			f, found, err := matchFunc(funcs, tt.typed)
+			require.NoError(t, err)
+			assert.Equal(t, tt.found, found)
+			if tt.found {
+				assert.Equal(t, tt.want, f.QualifiedName())
+			}
+		})
+	}
+}
+
+func TestMatchFuncAmbiguous(t *testing.T) {
+	funcs := []function{
+		{Name: "BuildAll"},
+		{Name: "Build_all"},
+		{Name: "Up", Group: "docker", Aliases: []string{"up"}},
+		{Name: "Up", Group: "compose", Aliases: []string{"up"}},
+	}
+	// an exact name is never ambiguous
+	f, found, err := matchFunc(funcs, "Build_all")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "Build_all", f.Name)
+
+	// but a name that's normalized to more than one function is
+	_, found, err = matchFunc(funcs, "build-all")
+	assert.False(t, found)
+	assert.EqualError(t, err, "build-all is ambiguous, it could be any of: BuildAll, Build_all")
+
+	// and so is an alias of more than one function
+	_, _, err = matchFunc(funcs, "up")
+	assert.EqualError(t, err, "up is ambiguous, it could be any of: docker:Up, compose:Up")
+	f, found, err = matchFunc(funcs, "compose:up")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "compose:Up", f.QualifiedName())
+}
+
+func TestSuggestNames(t *testing.T) {
+	names := []string{"build", "build-docker", "docker:up", "test", "lint"}
+	assert.Equal(t, []string{"build-docker"}, suggestNames(names, "build-dockr"))
+	assert.Equal(t, []string{"build"}, suggestNames(names, "biuld"))
+	assert.Equal(t, []string{"lint", "test"}, suggestNames(names, "tint"), "the closest name is first")
+	assert.Empty(t, suggestNames(names, "deploy-everything"))
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("build", "build"))
+	assert.Equal(t, 1, levenshtein("build", "buld"))
+	assert.Equal(t, 2, levenshtein("build", "biuld"))
+	assert.Equal(t, 3, levenshtein("kitten", "sitting"))
+	assert.Equal(t, 4, levenshtein("", "test"))
+}
@@ -12,6 +12,8 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
+	"reflect"
 	"slices"
 	"strconv"
 	"strings"
@@ -20,30 +22,82 @@ import (
 type call struct {
 	FuncName string
 	Args     []any
+	Pos      token.Pos // where the method is called, for errors
 	Next     *call
 	Previous *call
 }
 
-// parseGoGoCtx assumes that there are at least 1 calls to the pCtx.GoGoCtxVariableName in the function.
-// If none are found, the original function is returned. This can happen if they specify a gogo.Context in the function
-// signature but don't end up using it. Eventually, this should parse *all* uses of the context, but for now, it just
-// parses the first one.
-func parseGoGoCtx(pCtx *function, funcDecl *ast.FuncDecl) (*function, error) {
-	stmnt, found := findFirstUsageOfChain(funcDecl, pCtx.GoGoCtxVariableName)
+// settings remembers the value each property of a function is set to, and where, since the context
+// can be used anywhere in the function, and the same property can be set more than once.
+type settings struct {
+	fset     *token.FileSet
+	function string
+	set      map[string]setting
+}
+
+type setting struct {
+	value any
+	pos   token.Pos
+}
+
+// conflictError is returned when a property of a function is set to two different values
+type conflictError struct {
+	pos              token.Pos
+	Position         token.Position
+	PreviousPosition token.Position
+	Function         string
+	Property         string
+	Value            any
+	PreviousValue    any
+}
+
+func (e *conflictError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Position, e.describe())
+}
+
+// describe explains the conflict, without the position it's at
+func (e *conflictError) describe() string {
+	return fmt.Sprintf("%s of %s is set to %q, but it is already set to %q at %s",
+		e.Property, e.Function, fmt.Sprint(e.Value), fmt.Sprint(e.PreviousValue), e.PreviousPosition)
+}
+
+// record remembers the value a property is set to, returning an error if it's already set to something else
+func (s settings) record(property string, value any, pos token.Pos) error {
+	previous, found := s.set[property]
 	if !found {
-		// first argument is ctx, but it's not used
-		return pCtx, nil
+		s.set[property] = setting{value: value, pos: pos}
+		return nil
 	}
-
-	invertedChain := invertCallChain(stmnt)
-	if invertedChain == nil {
-		return nil, errors.New("could not invert call chain")
+	if reflect.DeepEqual(previous.value, value) {
+		return nil
+	}
+	return &conflictError{
+		pos:              pos,
+		Position:         s.fset.Position(pos),
+		PreviousPosition: s.fset.Position(previous.pos),
+		Function:         s.function,
+		Property:         property,
+		Value:            value,
+		PreviousValue:    previous.value,
 	}
+}
+
+// parseGoGoCtx collects the calls on the context in pCtx.GoGoCtxVariableName from anywhere in the function,
+// merging them in the order they appear. If there are none, the original function is returned. This can
+// happen if they specify a gogo.Context in the function signature but don't end up using it.
+func parseGoGoCtx(fset *token.FileSet, pCtx *function, funcDecl *ast.FuncDecl, values *packageValues) (*function, error) {
+	seen := settings{fset: fset, function: pCtx.Name, set: map[string]setting{}}
+	for _, chain := range findContextChains(funcDecl, pCtx.GoGoCtxVariableName) {
+		invertedChain := invertCallChain(chain, values)
+		if invertedChain == nil {
+			return nil, errors.New("could not invert call chain")
+		}
 
-	// We now walk the chain for "stmnt", which is "ctx" and its subsequent method calls:
-	err := processGoGoChain(invertedChain, pCtx)
-	if err != nil {
-		return nil, err
+		// We now walk the chain, which is "ctx" and its subsequent method calls:
+		err := processGoGoChain(invertedChain, pCtx, seen)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	pCtx.UseGoGoCtx = true
@@ -51,13 +105,13 @@ func parseGoGoCtx(pCtx *function, funcDecl *ast.FuncDecl) (*function, error) {
 }
 
 // processGoGoChain walks through the root of the expression chain and identifies method calls on ctx
-func processGoGoChain(ctx *call, pCtx *function) error {
+func processGoGoChain(ctx *call, pCtx *function, seen settings) error {
 	current := ctx
 	var err error
 	for current != nil {
 		// If the function is a method on the context, process it
 		// Process the method
-		current, err = processMethodOnContext(current, pCtx)
+		current, err = processMethodOnContext(current, pCtx, seen)
 		if err != nil {
 			return err
 		}
@@ -65,22 +119,41 @@ func processGoGoChain(ctx *call, pCtx *function) error {
 	return nil
 }
 
-func processMethodOnContext(current *call, ctx *function) (*call, error) {
+func processMethodOnContext(current *call, ctx *function, seen settings) (*call, error) {
 	// Process the method
 	switch current.FuncName {
 	case "ShortDescription":
 		if len(current.Args) == 1 {
+			if err := seen.record(current.FuncName, current.Args[0], current.Pos); err != nil {
+				return nil, err
+			}
 			ctx.Description = current.Args[0].(string)
 		}
 	case "Example":
 		if len(current.Args) == 1 {
+			if err := seen.record(current.FuncName, current.Args[0], current.Pos); err != nil {
+				return nil, err
+			}
 			ctx.Example = current.Args[0].(string)
 		}
+	case "Alias":
+		// aliases add up, rather than replacing the earlier ones
+		for _, alias := range current.Args {
+			name, ok := alias.(string)
+			if !ok || name == "" || strings.ContainsAny(name, GROUP_SEPARATOR+" ") {
+				return nil, fmt.Errorf("invalid alias %v for %s", alias, ctx.Name)
+			}
+			if !slices.Contains(ctx.Aliases, name) {
+				ctx.Aliases = append(ctx.Aliases, name)
+			}
+		}
+	case "Hidden":
+		ctx.Hidden = true
 	case "Argument":
 		if len(current.Args) == 1 {
 			argName := current.Args[0].(string)
 			var err error
-			current, ctx.Arguments, err = processMethodOnArgument(current.Next, argName, ctx.Arguments)
+			current, ctx.Arguments, err = processMethodOnArgument(current.Next, argName, ctx.Arguments, seen)
 			if err != nil {
 				return nil, err
 			}
@@ -90,7 +163,7 @@ func processMethodOnContext(current *call, ctx *function) (*call, error) {
 	return current.Next, nil
 }
 
-func processMethodOnArgument(current *call, argName string, args []argument) (*call, []argument, error) {
+func processMethodOnArgument(current *call, argName string, args []argument, seen settings) (*call, []argument, error) {
 	// check if this argument exists in the arg map already, as it should
 	argIndex := slices.IndexFunc(args, func(a argument) bool {
 		return a.Name == argName
@@ -100,6 +173,18 @@ func processMethodOnArgument(current *call, argName string, args []argument) (*c
 	}
 	arg := args[argIndex]
 	for current != nil {
+		// the argument's properties can also be set more than once, as long as it's to the same value
+		switch current.FuncName {
+		case "Name", "Short", "Default", "Help", "AllowedValues", "RestrictedValues", "Description":
+			property := fmt.Sprintf("Argument(%s).%s", argName, current.FuncName)
+			var value any = current.Args
+			if len(current.Args) == 1 {
+				value = current.Args[0]
+			}
+			if err := seen.record(property, value, current.Pos); err != nil {
+				return nil, nil, err
+			}
+		}
 		// begin walking the current call chain
 		switch current.FuncName {
 		case "Name":
@@ -119,7 +204,7 @@ func processMethodOnArgument(current *call, argName string, args []argument) (*c
 			}
 		case "Default":
 			if len(current.Args) == 1 {
-				arg.Default = current.Args[0]
+				arg.Default = current.Args[0].(string)
 			}
 		case "Help":
 			if len(current.Args) == 1 {
@@ -127,16 +212,18 @@ func processMethodOnArgument(current *call, argName string, args []argument) (*c
 			}
 		case "AllowedValues":
 			if len(current.Args) > 0 {
-				arg.AllowedValues = current.Args
+				arg.AllowedValues = stringArgs(current.Args)
 			}
 		case "RestrictedValues":
 			if len(current.Args) > 0 {
-				arg.RestrictedValues = current.Args
+				arg.RestrictedValues = stringArgs(current.Args)
 			}
 		case "Description":
 			if len(current.Args) == 1 {
 				arg.Description = current.Args[0].(string)
 			}
+		case "Required":
+			arg.Required = true
 		case "Argument":
 			// It's a new argument, return and let the caller handle it
 			args[argIndex] = arg
@@ -149,7 +236,7 @@ func processMethodOnArgument(current *call, argName string, args []argument) (*c
 	return current, args, nil
 }
 
-func invertCallChain(expr ast.Expr) *call {
+func invertCallChain(expr ast.Expr, values *packageValues) *call {
 	var root *call
 
 	for {
@@ -158,7 +245,11 @@ func invertCallChain(expr ast.Expr) *call {
 			// Create a new call node
 			newCall := &call{
 				FuncName: extractFuncName(node.Fun),
-				Args:     extractArgs(node.Args),
+				Args:     extractArgs(node.Args, values),
+				Pos:      node.Pos(),
+			}
+			if sel, ok := node.Fun.(*ast.SelectorExpr); ok {
+				newCall.Pos = sel.Sel.Pos()
 			}
 
 			if root != nil {
@@ -196,9 +287,17 @@ func extractFuncName(expr ast.Expr) string {
 	}
 }
 
-func extractArgs(args []ast.Expr) []any {
+// extractArgs returns the values of the arguments passed to a context method. Literals are used as they are
+// written, and constants and package-level variables are resolved to their values, when they can be.
+func extractArgs(args []ast.Expr, values *packageValues) []any {
 	var result []any
 	for _, arg := range args {
+		if _, isLit := arg.(*ast.BasicLit); !isLit {
+			if value, ok := values.value(arg); ok {
+				result = append(result, value)
+				continue
+			}
+		}
 		switch node := arg.(type) {
 		case *ast.BasicLit:
 			if node.Kind == token.STRING {
@@ -216,108 +315,52 @@ func extractArgs(args []ast.Expr) []any {
 			result = append(result, node.Name)
 		// Add more cases as needed for other types of arguments
 		default:
-			result = append(result, fmt.Sprintf("%T", node))
+			result = append(result, types.ExprString(node))
 		}
 	}
 	return result
 }
 
-// findFirstUsageOfChain locates the leaf method chain rooted in the provided context variable argument name.
-func findFirstUsageOfChain(funcDecl *ast.FuncDecl, argName string) (ast.Expr, bool) {
-	// Traverse the function body statements
-	for _, stmt := range funcDecl.Body.List {
-		// Recursively inspect the statement to find method chains using the specified argument.
-		if foundExpr := findUsageInStmt(stmt, argName); foundExpr != nil {
-			// convert stmt to expr
-			switch s := stmt.(type) {
-			case *ast.ExprStmt:
-				return s.X, true
-			}
-		}
+// stringArgs returns the values extractArgs resolved, which are all written as strings
+func stringArgs(args []any) []string {
+	values := make([]string, 0, len(args))
+	for _, arg := range args {
+		values = append(values, arg.(string))
 	}
-	// No usage found
-	return nil, false
+	return values
 }
 
-// findUsageInStmt inspects a given statement to find method calls rooted in the specified argument name.
-func findUsageInStmt(stmt ast.Stmt, argName string) ast.Expr {
-	switch s := stmt.(type) {
-	case *ast.ExprStmt:
-		// Expression statements (i.e., expressions as statements)
-		return findUsageInExpr(s.X, argName)
-	case *ast.AssignStmt:
-		// Assignment statements: check RHS (the expressions being assigned)
-		for _, rhs := range s.Rhs {
-			if expr := findUsageInExpr(rhs, argName); expr != nil {
-				return expr
-			}
-		}
-	case *ast.ReturnStmt:
-		// Return statements: check returned expressions
-		for _, result := range s.Results {
-			if expr := findUsageInExpr(result, argName); expr != nil {
-				return expr
-			}
-		}
-	case *ast.IfStmt:
-		// If statements: check condition, body, and else parts
-		if expr := findUsageInExpr(s.Cond, argName); expr != nil {
-			return expr
-		}
-		// Check body of if
-		for _, stmt := range s.Body.List {
-			if expr := findUsageInStmt(stmt, argName); expr != nil {
-				return expr
-			}
-		}
-		// Check else part (either a block or another statement)
-		if s.Else != nil {
-			if expr := findUsageInStmt(s.Else, argName); expr != nil {
-				return expr
-			}
-		}
-	case *ast.ForStmt:
-		// Check in for loop: init, condition, and post expressions
-		if expr := findUsageInStmt(s.Init, argName); expr != nil {
-			return expr
-		}
-		if expr := findUsageInExpr(s.Cond, argName); expr != nil {
-			return expr
-		}
-		for _, stmt := range s.Body.List {
-			if expr := findUsageInStmt(stmt, argName); expr != nil {
-				return expr
-			}
-		}
+// findContextChains finds each chain of method calls on the context variable anywhere in the function body,
+// including within switch, range and select statements, blocks and closures, in the order they appear.
+func findContextChains(funcDecl *ast.FuncDecl, argName string) []ast.Expr {
+	if funcDecl.Body == nil {
+		return nil
 	}
-	return nil
-}
-
-// findUsageInExpr checks for method chains or expressions rooted in the context variable name.
-func findUsageInExpr(expr ast.Expr, argName string) ast.Expr {
-	switch e := expr.(type) {
-	case *ast.CallExpr:
-		// Check if a function call (method) expression is rooted in the desired argument name
-		if selExpr, ok := e.Fun.(*ast.SelectorExpr); ok {
-			if rootIsArg(selExpr.X, argName) {
-				return e // Return the method call if it's rooted in the context variable
-			}
-			return findUsageInExpr(selExpr.X, argName) // Recursively check the method receiver
-		}
-	case *ast.SelectorExpr:
-		// Check a selector expression (e.g., obj.Method)
-		if rootIsArg(e.X, argName) {
-			return e // Return the selector expression if rooted in the context variable
+	var chains []ast.Expr
+	ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+		callExpr, ok := node.(*ast.CallExpr)
+		if !ok || !rootIsArg(callExpr, argName) {
+			return true
 		}
-		return findUsageInExpr(e.X, argName) // Recursively check the receiver
-	}
-	return nil
+		// the outermost call is found first, and it includes the rest of the chain
+		chains = append(chains, callExpr)
+		return false
+	})
+	return chains
 }
 
-// rootIsArg walks up the tree of expressions to check if the root is the specified argument name.
+// rootIsArg walks down the chain of calls and selectors to check if the root is the specified argument name.
 func rootIsArg(expr ast.Expr, argName string) bool {
-	if ident, ok := expr.(*ast.Ident); ok && ident.Name == argName {
-		return true
+	for {
+		switch e := expr.(type) {
+		case *ast.CallExpr:
+			expr = e.Fun
+		case *ast.SelectorExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name == argName
+		default:
+			return false
+		}
 	}
-	return false
 }
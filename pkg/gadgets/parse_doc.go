@@ -0,0 +1,210 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/doc/comment"
+	"strings"
+	"unicode"
+)
+
+// This file reads the help of a function from the conventions of its doc comment, so a function without a
+// context is described as well as one that uses it:
+//
+//	// Deploy deploys the services to the environment. The first sentence is the short description.
+//	//
+//	// Example:
+//	//
+//	//	gogo deploy prod --wait 1m
+//	//
+//	// Args:
+//	//   - env: the environment to deploy to
+//	//   - wait: how long to wait for the services to start
+//	func Deploy(env string, wait time.Duration) error
+//
+// The calls on the context override what's read from the doc comment.
+
+// The lines that start a section of the doc comment, instead of being part of the description
+const (
+	DOC_EXAMPLE = "Example:"
+	DOC_ARGS    = "Args:"
+	DOC_FLAGS   = "Flags:"
+)
+
+// docComment is what the doc comment of a function describes
+type docComment struct {
+	short   string            // the first sentence of the description
+	long    string            // the description, which is the doc comment without its sections
+	example string            // the lines of the Example section
+	args    map[string]string // the help of each argument, from the Args or Flags section, by its name
+}
+
+// parseDoc splits the doc comment into its description and sections. A section starts with its own line, like
+// `Args:`, and is the rest of the paragraph, or the indented block or list that follows it.
+func parseDoc(group *ast.CommentGroup) docComment {
+	parsed := docComment{args: map[string]string{}}
+	if group == nil {
+		return parsed
+	}
+	var parser comment.Parser
+	blocks := parser.Parse(group.Text()).Content
+	var description []string
+	for i := 0; i < len(blocks); i++ {
+		para, ok := blocks[i].(*comment.Paragraph)
+		if !ok {
+			description = append(description, blockText(blocks[i]))
+			continue
+		}
+		// the lines of the paragraph before a section are part of the description
+		section := ""
+		var lines []string
+		for _, line := range strings.Split(inlineText(para.Text), "\n") {
+			if header := strings.TrimSpace(line); isDocSection(header) {
+				parsed.addSection(section, lines, &description)
+				section, lines = header, nil
+				continue
+			}
+			lines = append(lines, line)
+		}
+		// a section is usually indented, which makes it the block after its line
+		if section != "" && len(lines) == 0 && i+1 < len(blocks) {
+			if next := sectionLines(blocks[i+1]); next != nil {
+				lines = next
+				i++
+			}
+		}
+		parsed.addSection(section, lines, &description)
+	}
+	parsed.long = strings.TrimSpace(strings.Join(description, "\n\n"))
+	parsed.short = new(doc.Package).Synopsis(parsed.long)
+	return parsed
+}
+
+// addSection adds the lines to the section they're in, or to the description when they aren't in one
+func (d *docComment) addSection(section string, lines []string, description *[]string) {
+	switch section {
+	case "":
+		if len(lines) > 0 {
+			*description = append(*description, strings.Join(lines, "\n"))
+		}
+	case DOC_EXAMPLE:
+		example := strings.TrimSpace(strings.Join(lines, "\n"))
+		if d.example != "" && example != "" {
+			example = d.example + "\n" + example
+		}
+		if example != "" {
+			d.example = example
+		}
+	case DOC_ARGS, DOC_FLAGS:
+		// each argument is written as `name: help text`, and a line without a name continues the help before it
+		last := ""
+		for _, line := range lines {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			name, help, found := strings.Cut(line, ":")
+			name = strings.TrimLeft(strings.TrimSpace(name), "-")
+			if found && isArgumentName(name) {
+				d.args[name] = strings.TrimSpace(help)
+				last = name
+			} else if last != "" {
+				d.args[last] = strings.TrimSpace(d.args[last] + " " + line)
+			}
+		}
+	}
+}
+
+func isDocSection(line string) bool {
+	return line == DOC_EXAMPLE || line == DOC_ARGS || line == DOC_FLAGS
+}
+
+// isArgumentName checks if the text is the name of an argument, or of its flag, like `wait` or `dry-run`
+func isArgumentName(text string) bool {
+	if text == "" {
+		return false
+	}
+	for _, r := range text {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// sectionLines returns the lines of an indented block or list, or nil for any other block
+func sectionLines(block comment.Block) []string {
+	switch block := block.(type) {
+	case *comment.Code:
+		return strings.Split(strings.TrimRight(block.Text, "\n"), "\n")
+	case *comment.List:
+		var lines []string
+		for _, item := range block.Items {
+			var text []string
+			for _, content := range item.Content {
+				text = append(text, blockText(content))
+			}
+			// an item can be wrapped over several lines, but it's a single entry
+			lines = append(lines, strings.ReplaceAll(strings.Join(text, " "), "\n", " "))
+		}
+		return lines
+	}
+	return nil
+}
+
+// blockText returns the text of a block of the description, as it's written in the doc comment
+func blockText(block comment.Block) string {
+	switch block := block.(type) {
+	case *comment.Paragraph:
+		return inlineText(block.Text)
+	case *comment.Heading:
+		return inlineText(block.Text)
+	case *comment.Code:
+		return "\t" + strings.ReplaceAll(strings.TrimRight(block.Text, "\n"), "\n", "\n\t")
+	case *comment.List:
+		lines := sectionLines(block)
+		for i, line := range lines {
+			lines[i] = "  - " + line
+		}
+		return strings.Join(lines, "\n")
+	}
+	return ""
+}
+
+// inlineText returns the text of a paragraph, with the text of its links
+func inlineText(texts []comment.Text) string {
+	var b strings.Builder
+	for _, text := range texts {
+		switch text := text.(type) {
+		case comment.Plain:
+			b.WriteString(string(text))
+		case comment.Italic:
+			b.WriteString(string(text))
+		case *comment.Link:
+			b.WriteString(inlineText(text.Text))
+		case *comment.DocLink:
+			b.WriteString(inlineText(text.Text))
+		}
+	}
+	return b.String()
+}
+
+// applyArgumentHelp sets the help of the arguments, and the fields of an options struct, that are in the Args
+// section of the doc comment, by their name or the name of their flag. A field's own doc comment is kept.
+func applyArgumentHelp(args []argument, help map[string]string) {
+	for i := range args {
+		for _, name := range []string{args[i].Name, args[i].Long, kebabCase(args[i].Name)} {
+			if text, found := help[name]; found && name != "" && args[i].Help == "" {
+				args[i].Help = text
+			}
+		}
+		applyArgumentHelp(args[i].Fields, help)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"fmt"
+	"go/ast"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDoc(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		expected docComment
+	}{
+		{
+			name: "description only",
+			doc: `// Deploy deploys the services. It waits for them
+// to start.`,
+			expected: docComment{
+				short: "Deploy deploys the services.",
+				long:  "Deploy deploys the services. It waits for them\nto start.",
+				args:  map[string]string{},
+			},
+		},
+		{
+			name: "indented sections",
+			doc: `// Deploy deploys the services. It waits for them to start.
+//
+// Example:
+//
+//	gogo deploy prod --wait 1m
+//	gogo deploy dev
+//
+// Args:
+//   - env: the environment to deploy to
+//   - wait: how long to wait for the services
+//     to start`,
+			expected: docComment{
+				short:   "Deploy deploys the services.",
+				long:    "Deploy deploys the services. It waits for them to start.",
+				example: "gogo deploy prod --wait 1m\ngogo deploy dev",
+				args: map[string]string{
+					"env":  "the environment to deploy to",
+					"wait": "how long to wait for the services to start",
+				},
+			},
+		},
+		{
+			name: "sections without blank lines",
+			doc: `// Deploy deploys the services.
+// Example:
+//	gogo deploy prod
+// Flags:
+//	--dry-run: only print what would be deployed
+//	env: the environment`,
+			expected: docComment{
+				short:   "Deploy deploys the services.",
+				long:    "Deploy deploys the services.",
+				example: "gogo deploy prod",
+				args:    map[string]string{"dry-run": "only print what would be deployed", "env": "the environment"},
+			},
+		},
+		{
+			name: "unindented args",
+			doc: `// Deploy deploys the services.
+//
+// Args:
+// env: the environment
+// to deploy to
+//
+// The services are deployed in parallel.`,
+			expected: docComment{
+				short: "Deploy deploys the services.",
+				long:  "Deploy deploys the services.\n\nThe services are deployed in parallel.",
+				args:  map[string]string{"env": "the environment to deploy to"},
+			},
+		},
+		{
+			name: "code in the description",
+			doc: `// Deploy deploys the services, like:
+//
+//	kubectl apply -f services.yaml`,
+			expected: docComment{
+				short: "Deploy deploys the services, like:",
+				long:  "Deploy deploys the services, like:\n\n\tkubectl apply -f services.yaml",
+				args:  map[string]string{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := fmt.Sprintf("package gadgets\n%s\nfunc Deploy() {}", tt.doc)
+			checked := checkSources([]string{"deploy.go"}, []string{src})
+			funcDecl := checked.files[0].Decls[0].(*ast.FuncDecl)
+			assert.Equal(t, tt.expected, parseDoc(funcDecl.Doc))
+		})
+	}
+	assert.Equal(t, docComment{args: map[string]string{}}, parseDoc(nil))
+}
+
+func TestParseDocConventions(t *testing.T) {
+	funcs, err := parseSource(fmt.Sprintf(`package gadgets
+import "%s"
+
+type Options struct {
+	// Env is where to deploy
+	Env string
+	DryRun bool
+}
+
+// Deploy deploys the services.
+//
+// Example:
+//
+//	gogo deploy prod
+//
+// Args:
+//   - env: the environment
+//   - wait: how long to wait
+func Deploy(env string, wait int) {}
+
+// Build builds the binaries.
+//
+// Example:
+//
+//	gogo build linux
+//
+// Args:
+//   - target: the platform
+//   - verbose: print more
+func Build(ctx gogo.Context, target string, verbose bool) {
+	ctx.ShortDescription("Build it")
+	ctx.Argument(target).Help("the platform to build for")
+}
+
+// Release releases the binaries.
+//
+// Flags:
+//   - env: not used, since the field has its own help
+//   - dry-run: only print the release
+func Release(opts Options) {}`, GOGOIMPORTPATH))
+	require.NoError(t, err)
+	require.Len(t, funcs, 3)
+
+	assert.Equal(t, "Deploy deploys the services.", funcs[0].Description)
+	assert.Equal(t, "Deploy deploys the services.", funcs[0].Comment)
+	assert.Equal(t, "gogo deploy prod", funcs[0].Example)
+	assert.Equal(t, "the environment", funcs[0].Arguments[0].Help)
+	assert.Equal(t, "how long to wait", funcs[0].Arguments[1].Help)
+
+	// the context overrides the doc comment
+	assert.Equal(t, "Build it", funcs[1].Description)
+	assert.Equal(t, "gogo build linux", funcs[1].Example)
+	assert.Equal(t, "the platform to build for", funcs[1].Arguments[0].Help)
+	assert.Equal(t, "print more", funcs[1].Arguments[1].Help)
+
+	// the fields of an options struct are matched by their flag name too
+	fields := funcs[2].Arguments[0].Fields
+	require.Len(t, fields, 2)
+	assert.Equal(t, "Env is where to deploy", fields[0].Help)
+	assert.Equal(t, "only print the release", fields[1].Help)
+}
+
+func TestCommandDescription(t *testing.T) {
+	assert.Equal(t, "Deploys it.", commandDescription(GoCmd{Long: "Deploys\n it."}))
+	assert.Equal(t, `Deploys it.\n\nExample:\n  gogo deploy \"prod\"\n  gogo deploy dev`,
+		commandDescription(GoCmd{Long: "Deploys it.", Example: "gogo deploy \"prod\"\ngogo deploy dev"}))
+	assert.Equal(t, `Example:\n  gogo deploy`, commandDescription(GoCmd{Example: "gogo deploy"}))
+}
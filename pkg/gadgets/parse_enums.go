@@ -0,0 +1,143 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+)
+
+// This file finds the enum types of the gadgets, which are named types with a const block of the values
+// they can have, like:
+//
+//	type Env string
+//
+//	const (
+//		// Dev is the local stack
+//		Dev  Env = "dev"
+//		Prod Env = "prod"
+//	)
+//
+// An argument of an enum type is only allowed to have one of the values, without the function calling
+// ctx.Argument(env).AllowedValues(...), and the doc comments of the constants describe the values in the help.
+
+// enumType is a named type declared with the gadgets, and the constants declared with it
+type enumType struct {
+	Underlying string      // the scalar type the type is declared as, which its values are parsed as
+	Values     []enumValue // the values of the constants, in the order they're declared
+}
+
+// enumValue is a value of an enum type, from one of its constants
+type enumValue struct {
+	Value string // the value written like the AllowedValues of the context methods
+	Doc   string // the doc comment of the constant, without the constant's name
+}
+
+// allowed returns the values of the enum, as the argument's AllowedValues
+func (e enumType) allowed() []string {
+	var allowed []string
+	for _, value := range e.Values {
+		if !slices.Contains(allowed, value.Value) {
+			allowed = append(allowed, value.Value)
+		}
+	}
+	return allowed
+}
+
+// findEnumTypes returns the named types of the function arguments, and of the fields of their options structs,
+// that are declared with the gadgets as one of the scalarTypes and have constants, keyed by the name of the type.
+func findEnumTypes(checked *checkedPackage, structs map[string]*ast.StructType) map[string]enumType {
+	candidates := map[string]bool{}
+	for _, expr := range textTypeCandidates(checked, structs) {
+		candidates[checked.typeString(expr)] = true
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	enums := map[string]enumType{}
+	for _, file := range checked.files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				valueSpec := spec.(*ast.ValueSpec)
+				for _, name := range valueSpec.Names {
+					obj, ok := checked.info.Defs[name].(*types.Const)
+					if !ok || obj.Val() == nil {
+						continue
+					}
+					named, ok := types.Unalias(obj.Type()).(*types.Named)
+					if !ok || named.Obj().Pkg() != checked.pkg || !candidates[named.Obj().Name()] {
+						continue
+					}
+					basic, ok := named.Underlying().(*types.Basic)
+					if !ok {
+						continue
+					}
+					if _, found := scalarTypes[basic.Name()]; !found {
+						continue
+					}
+					enum := enums[named.Obj().Name()]
+					enum.Underlying = basic.Name()
+					enum.Values = append(enum.Values, enumValue{
+						Value: constantText(obj.Type(), obj.Val()),
+						Doc:   constantDoc(name.Name, valueSpec),
+					})
+					enums[named.Obj().Name()] = enum
+				}
+			}
+		}
+	}
+	return enums
+}
+
+// constantDoc returns the doc comment of the constant, or the comment after it, without the
+// "<name> is" the doc comment starts with
+func constantDoc(name string, spec *ast.ValueSpec) string {
+	var doc string
+	if spec.Doc != nil {
+		doc = spec.Doc.Text()
+	} else if spec.Comment != nil {
+		doc = spec.Comment.Text()
+	}
+	// the help is written in a string and a struct tag of the generated code, so it's kept on one line, without quotes
+	doc = strings.Join(strings.Fields(doc), " ")
+	doc = strings.NewReplacer(`"`, "'", "`", "'").Replace(doc)
+	if rest, found := strings.CutPrefix(doc, name+" "); found {
+		doc = strings.TrimPrefix(rest, "is ")
+	}
+	return doc
+}
+
+// enumHelp describes the values an enum argument can have, out of the values it's allowed to have
+func enumHelp(values []enumValue, allowed []string) string {
+	var described []string
+	seen := map[string]bool{}
+	for _, value := range values {
+		if seen[value.Value] || !slices.Contains(allowed, value.Value) {
+			continue
+		}
+		seen[value.Value] = true
+		if value.Doc == "" {
+			described = append(described, value.Value)
+			continue
+		}
+		described = append(described, fmt.Sprintf("%s (%s)", value.Value, value.Doc))
+	}
+	if len(described) == 0 {
+		return ""
+	}
+	return "one of: " + strings.Join(described, ", ")
+}
@@ -0,0 +1,101 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEnumTypes(t *testing.T) {
+	funcs, err := parseSource(`package main
+
+type Level string
+
+const (
+	// LevelQuiet is only errors
+	LevelQuiet Level = "quiet"
+	LevelInfo  Level = "info" // what's done
+	LevelDebug Level = "debug"
+)
+
+type Priority int
+
+const (
+	Low Priority = iota
+	High
+)
+
+// Env implements encoding.TextUnmarshaler, and is still only allowed to be one of its constants
+type Env string
+
+func (e *Env) UnmarshalText(text []byte) error { *e = Env(text); return nil }
+
+const EnvDev Env = "dev"
+
+// Region has no constants, so it isn't an enum
+type Region string
+
+func Log(level Level, priority Priority, env Env) {}
+
+func Deploy(region Region) {}`)
+	require.NoError(t, err)
+	require.Len(t, funcs, 1)
+
+	expected := []argument{
+		{
+			Name:          "level",
+			Type:          "Level",
+			Underlying:    "string",
+			AllowedValues: []string{"quiet", "info", "debug"},
+			Enum: []enumValue{
+				{Value: "quiet", Doc: "only errors"},
+				{Value: "info", Doc: "what's done"},
+				{Value: "debug"},
+			},
+		},
+		{
+			Name:          "priority",
+			Type:          "Priority",
+			Underlying:    "int",
+			AllowedValues: []string{"0", "1"},
+			Enum:          []enumValue{{Value: "0"}, {Value: "1"}},
+		},
+		{
+			Name:          "env",
+			Type:          "Env",
+			Text:          true,
+			AllowedValues: []string{"dev"},
+			Enum:          []enumValue{{Value: "dev"}},
+		},
+	}
+	assert.Equal(t, expected, funcs[0].Arguments)
+}
+
+func TestConvertEnumArgument(t *testing.T) {
+	level := argument{
+		Name:          "level",
+		Type:          "Level",
+		Underlying:    "string",
+		Help:          "how much to log",
+		AllowedValues: []string{"quiet", "debug"},
+		Enum: []enumValue{
+			{Value: "quiet", Doc: "only errors"},
+			{Value: "info", Doc: "what's done"},
+			{Value: "debug"},
+		},
+	}
+	flag := convertToGoFlag(function{Name: "Log", Package: "ops"}, level)
+	assert.Equal(t, "opsGadgets.Level", flag.Type)
+	assert.Equal(t, "how much to log; one of: quiet (only errors), debug", flag.Help)
+	assert.Equal(t, "String", flagType(flag))
+	assert.Equal(t, "opsGadgets.Level", optionType(flag))
+	assert.False(t, hasLiteralDefault(flag))
+}
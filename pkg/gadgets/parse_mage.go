@@ -0,0 +1,203 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// This file handles the parts of the mage API that change how functions are found and named,
+// so magefiles run unchanged. The rest of the API, like mg.Deps, is plain go that runs as-is.
+
+const MAGEIMPORTPATH = "github.com/magefile/mage/mg"
+
+const (
+	MAGE_DEFAULT_VAR = "Default" // `var Default = Build` sets the function run when no function is given
+	MAGE_ALIASES_VAR = "Aliases" // `var Aliases = map[string]interface{}{"b": Build}` sets other names for functions
+)
+
+// getImportName finds the alias or default name of the import with the given path
+func getImportName(file *ast.File, importPath string) (string, bool) {
+	for _, imp := range file.Imports {
+		if imp.Path.Value != strconv.Quote(importPath) {
+			continue
+		}
+		if imp.Name != nil {
+			return imp.Name.Name, true
+		}
+		return importPath[strings.LastIndex(importPath, "/")+1:], true
+	}
+	return "", false
+}
+
+// findNamespaces returns the types declared as a mage namespace (e.g. `type Docker mg.Namespace`)
+// in any of the files, with the short description from their doc comment. The methods on these types
+// are run as `<namespace>:<method>`.
+func findNamespaces(files []*ast.File) map[string]string {
+	namespaces := map[string]string{}
+	for _, file := range files {
+		mgAlias, found := getImportName(file, MAGEIMPORTPATH)
+		if !found {
+			continue
+		}
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				if isSelector(typeSpec.Type, mgAlias, "Namespace") {
+					namespaces[typeSpec.Name.Name] = parseDoc(typeDoc(genDecl, typeSpec)).short
+				}
+			}
+		}
+	}
+	return namespaces
+}
+
+// namespaceGroup returns the command group of a mage namespace. Like mage, the name is lower case.
+func namespaceGroup(typeName string) string {
+	return strings.ToLower(typeName)
+}
+
+// typeDoc returns the doc comment of the type, which is on the declaration when it only declares the type
+func typeDoc(genDecl *ast.GenDecl, typeSpec *ast.TypeSpec) *ast.CommentGroup {
+	if typeSpec.Doc == nil && !genDecl.Lparen.IsValid() {
+		return genDecl.Doc
+	}
+	return typeSpec.Doc
+}
+
+// receiverNamespace returns the namespace type of the method's receiver, if it is one.
+// The receiver can be a pointer to the type, since the method is called on a new value of it.
+func receiverNamespace(funcDecl *ast.FuncDecl, namespaces map[string]string) (string, bool) {
+	if funcDecl.Recv == nil || len(funcDecl.Recv.List) != 1 {
+		return "", false
+	}
+	recv := funcDecl.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	ident, ok := recv.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	if _, found := namespaces[ident.Name]; !found {
+		return "", false
+	}
+	return ident.Name, true
+}
+
+// isSelector checks if the expression is <x>.<sel>
+func isSelector(expr ast.Expr, x, sel string) bool {
+	selExpr, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	ident, ok := selExpr.X.(*ast.Ident)
+	return ok && ident.Name == x && selExpr.Sel.Name == sel
+}
+
+// findMageTargets reads the Default and Aliases variables from the files, returning the qualified
+// name of the default function, and a map of each alias to the qualified name of its function.
+func findMageTargets(files []*ast.File, namespaces map[string]string) (string, map[string]string, error) {
+	var defaultTarget string
+	aliases := map[string]string{}
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				valueSpec := spec.(*ast.ValueSpec)
+				for i, name := range valueSpec.Names {
+					if i >= len(valueSpec.Values) {
+						continue
+					}
+					var err error
+					switch name.Name {
+					case MAGE_DEFAULT_VAR:
+						defaultTarget, err = targetName(valueSpec.Values[i], namespaces)
+					case MAGE_ALIASES_VAR:
+						err = parseAliases(valueSpec.Values[i], namespaces, aliases)
+					}
+					if err != nil {
+						return "", nil, fmt.Errorf("invalid %s variable: %w", name.Name, err)
+					}
+				}
+			}
+		}
+	}
+	return defaultTarget, aliases, nil
+}
+
+// parseAliases reads the entries of the Aliases map literal into the aliases
+func parseAliases(expr ast.Expr, namespaces map[string]string, aliases map[string]string) error {
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return fmt.Errorf("expected a map literal")
+	}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			return fmt.Errorf("expected a key value pair")
+		}
+		key, ok := kv.Key.(*ast.BasicLit)
+		if !ok || key.Kind != token.STRING {
+			return fmt.Errorf("expected a string alias")
+		}
+		alias, err := strconv.Unquote(key.Value)
+		if err != nil {
+			return err
+		}
+		target, err := targetName(kv.Value, namespaces)
+		if err != nil {
+			return err
+		}
+		aliases[alias] = target
+	}
+	return nil
+}
+
+// targetName converts a reference to a function, like `Build` or `Docker.Build`, into its qualified name
+func targetName(expr ast.Expr, namespaces map[string]string) (string, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name, nil
+	case *ast.SelectorExpr:
+		if ident, ok := t.X.(*ast.Ident); ok {
+			if _, found := namespaces[ident.Name]; found {
+				return namespaceGroup(ident.Name) + GROUP_SEPARATOR + t.Sel.Name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unsupported target %s", exprToTypeStr(expr))
+}
+
+// applyMageTargets marks the default function, and adds the aliases to their functions
+func applyMageTargets(funcs []function, defaultTarget string, aliases map[string]string) {
+	for i := range funcs {
+		name := funcs[i].QualifiedName()
+		if name == defaultTarget {
+			funcs[i].Default = true
+		}
+		for alias, target := range aliases {
+			if target == name {
+				funcs[i].Aliases = append(funcs[i].Aliases, alias)
+			}
+		}
+		slices.Sort(funcs[i].Aliases)
+	}
+}
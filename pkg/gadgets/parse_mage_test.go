@@ -0,0 +1,95 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"log"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/2bit-software/gogo/pkg/mod"
+	"github.com/2bit-software/gogo/pkg/sh"
+)
+
+func TestParseMagefile(t *testing.T) {
+	root, err := mod.FindModuleRoot()
+	require.NoError(t, err)
+
+	funcs, err := parse(path.Join(root, "scenarios", "mage", "magefile.go"))
+	require.NoError(t, err)
+	byName := map[string]function{}
+	for _, f := range funcs {
+		byName[f.QualifiedName()] = f
+	}
+	// unexported methods, and methods on types that aren't namespaces, are not targets
+	assert.Len(t, byName, 3)
+
+	build := byName["Build"]
+	assert.True(t, build.Default)
+	assert.Equal(t, []string{"b"}, build.Aliases)
+
+	up := byName["docker:Up"]
+	assert.Equal(t, "Docker", up.Receiver)
+	assert.Equal(t, []string{"up"}, up.Aliases)
+	// the context.Context is passed by gogo, and is not an argument
+	assert.True(t, up.UseGoGoCtx)
+	require.Len(t, up.Arguments, 1)
+	assert.Equal(t, "detach", up.Arguments[0].Name)
+}
+
+func TestParseMageTargetsAcrossFiles(t *testing.T) {
+	funcs, err := parseSources([]string{
+		`package main
+import "github.com/magefile/mage/mg"
+type DB mg.Namespace
+var Default = DB.Migrate`,
+		`package main
+var Aliases = map[string]any{"m": DB.Migrate}
+func (DB) Migrate() {}`,
+	})
+	require.NoError(t, err)
+	require.Len(t, funcs, 1)
+	assert.Equal(t, "db:Migrate", funcs[0].QualifiedName())
+	assert.True(t, funcs[0].Default)
+	assert.Equal(t, []string{"m"}, funcs[0].Aliases)
+}
+
+func TestBuildMagefile(t *testing.T) {
+	l := log.New(os.Stdout, "", log.LstdFlags)
+	root, err := mod.FindModuleRoot()
+	require.NoError(t, err)
+	scenarioDir := path.Join(root, "scenarios", "mage")
+	tmpDir := t.TempDir()
+
+	// the alias resolves to the namespaced function
+	source, found, err := findLocalFunc(scenarioDir, "up", gogoTags, gogoFolders)
+	require.NoError(t, err)
+	require.True(t, found)
+
+	opts := BuildOpts{
+		DisableCache:   true,
+		SourceDir:      source.Dir,
+		SourceFiles:    source.buildFiles(),
+		BinaryFilepath: path.Join(tmpDir, "magefile"),
+	}
+	err = Build(l, opts)
+	require.NoError(t, err)
+
+	out, err := sh.Cmd(opts.BinaryFilepath).SetArgs("Build").String()
+	require.NoError(t, err)
+	assert.Equal(t, "generate\nbuild", strings.TrimSpace(out))
+
+	out, err = sh.Cmd(opts.BinaryFilepath).SetArgs("docker", "Up", "--detach").String()
+	require.NoError(t, err)
+	assert.Equal(t, "generate\ndocker up detach=true", strings.TrimSpace(out))
+}
@@ -0,0 +1,133 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"go/ast"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// This file handles functions that take their arguments as a struct, e.g. `func Deploy(o DeployOpts)`.
+// Each exported field of the struct becomes a flag, so long signatures stay readable:
+//
+//	type DeployOpts struct {
+//		// Env is the environment to deploy to
+//		Env      string `short:"e" default:"staging"`
+//		Replicas int    `long:"count" env:"DEPLOY_REPLICAS" help:"how many replicas to run"`
+//	}
+
+// packageTypes are the types of a package that decide which arguments a function can have, beyond the scalarTypes
+type packageTypes struct {
+	checked *checkedPackage       // the package the functions are in, with the types of their arguments
+	text    map[string]textType   // the types implementing encoding.TextUnmarshaler
+	options map[string][]argument // the struct types a function can take its options as, with a flag argument for each field
+	enums   map[string]enumType   // the named types declared with constants for the values they can have
+	values  *packageValues        // the values of the constants and variables passed to the context methods
+}
+
+// findStructTypes returns the struct types declared in the files, by their name
+func findStructTypes(files []*ast.File) map[string]*ast.StructType {
+	structs := map[string]*ast.StructType{}
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				// a generic struct would need its type arguments to be filled in
+				if structType, ok := typeSpec.Type.(*ast.StructType); ok && typeSpec.TypeParams == nil {
+					structs[typeSpec.Name.Name] = structType
+				}
+			}
+		}
+	}
+	return structs
+}
+
+// findOptionStructs returns the struct types that a function can take its options as, which are the ones where
+// every exported field has a supported type. The fields are returned as the arguments they become flags for.
+func findOptionStructs(structs map[string]*ast.StructType, pkgTypes packageTypes) map[string][]argument {
+	options := map[string][]argument{}
+	for name, structType := range structs {
+		if _, isText := pkgTypes.text[name]; isText {
+			continue
+		}
+		if fields, ok := optionFields(structType, pkgTypes); ok {
+			options[name] = fields
+		}
+	}
+	return options
+}
+
+// optionFields converts the exported fields of the struct into arguments, or reports that one of them can't be one.
+// Unexported fields are left for the function to fill in.
+func optionFields(structType *ast.StructType, pkgTypes packageTypes) ([]argument, bool) {
+	var fields []argument
+	for _, field := range structType.Fields.List {
+		// an embedded struct's fields would have to be promoted, which isn't supported
+		if len(field.Names) == 0 {
+			return nil, false
+		}
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+			if !isSupportedType(field, pkgTypes) {
+				return nil, false
+			}
+			fields = append(fields, structFieldArgument(name.Name, field, pkgTypes))
+		}
+	}
+	return fields, len(fields) > 0
+}
+
+// structFieldArgument converts a field of an options struct into the argument for its flag. The flag is named by
+// the `long` tag, or the kebab-case field name, and described by the `help` tag, or the field's comment.
+func structFieldArgument(name string, field *ast.Field, pkgTypes packageTypes) argument {
+	arg := typedArgument(name, pkgTypes.checked.typeString(field.Type), pkgTypes)
+	arg.Long = kebabCase(name)
+	if field.Doc != nil {
+		arg.Help = strings.TrimSpace(field.Doc.Text())
+	} else if field.Comment != nil {
+		arg.Help = strings.TrimSpace(field.Comment.Text())
+	}
+	if field.Tag == nil {
+		return arg
+	}
+	tagValue, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return arg
+	}
+	tag := reflect.StructTag(tagValue)
+	if long := tag.Get("long"); long != "" {
+		arg.Long = long
+	}
+	if short := tag.Get("short"); short != "" {
+		arg.Short = short[0]
+	}
+	if help := tag.Get("help"); help != "" {
+		arg.Help = help
+	}
+	if def, found := tag.Lookup("default"); found {
+		arg.Default = def
+	}
+	arg.Env = tag.Get("env")
+	arg.Required, _ = strconv.ParseBool(tag.Get("required"))
+	return arg
+}
+
+// isOptionsParam determines if the parameter is a struct the function takes its options as
+func isOptionsParam(param *ast.Field, pkgTypes packageTypes) bool {
+	_, found := pkgTypes.options[pkgTypes.checked.typeString(param.Type)]
+	return found
+}
@@ -0,0 +1,135 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOptionsStruct(t *testing.T) {
+	funcs, err := parseSource(`package main
+import (
+	"time"
+	"github.com/2bit-software/gogo/pkg/gogo"
+)
+
+type DeployOpts struct {
+	// Env is the environment to deploy to
+	Env      string ` + "`short:\"e\" default:\"staging\"`" + `
+	Replicas int    ` + "`long:\"count\" env:\"DEPLOY_REPLICAS\" help:\"how many replicas to run\"`" + `
+	DryRun   bool   // only show what would be deployed
+	Timeout  time.Duration ` + "`required:\"true\"`" + `
+	notes    string
+}
+
+func Deploy(ctx gogo.Context, o DeployOpts) error { return nil }`)
+	require.NoError(t, err)
+	require.Len(t, funcs, 1)
+	require.Len(t, funcs[0].Arguments, 1)
+	opts := funcs[0].Arguments[0]
+	assert.Equal(t, "DeployOpts", opts.Type)
+
+	expected := []argument{
+		{Name: "Env", Type: "string", Long: "env", Short: 'e', Default: "staging", Help: "Env is the environment to deploy to"},
+		{Name: "Replicas", Type: "int", Long: "count", Env: "DEPLOY_REPLICAS", Help: "how many replicas to run"},
+		{Name: "DryRun", Type: "bool", Long: "dry-run", Help: "only show what would be deployed"},
+		{Name: "Timeout", Type: "time.Duration", Long: "timeout", Required: true},
+	}
+	assert.Equal(t, expected, opts.Fields)
+}
+
+func TestParseOptionsStructSignatures(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		supported bool
+	}{
+		{
+			name:      "options struct",
+			src:       "type Opts struct{ Name string }\nfunc Task(o Opts) {}",
+			supported: true,
+		},
+		{
+			name:      "options struct with a context",
+			src:       "import \"context\"\ntype Opts struct{ Name string }\nfunc Task(ctx context.Context, o Opts) {}",
+			supported: true,
+		},
+		{
+			name:      "options struct with a text type field",
+			src:       "import \"net/netip\"\ntype Opts struct{ Addr netip.Addr }\nfunc Task(o Opts) {}",
+			supported: true,
+		},
+		{
+			name:      "options struct and other arguments",
+			src:       "type Opts struct{ Name string }\nfunc Task(o Opts, verbose bool) {}",
+			supported: false,
+		},
+		{
+			name:      "two options structs",
+			src:       "type Opts struct{ Name string }\nfunc Task(a, b Opts) {}",
+			supported: false,
+		},
+		{
+			name:      "unsupported field type",
+			src:       "type Opts struct{ Labels map[string]string }\nfunc Task(o Opts) {}",
+			supported: false,
+		},
+		{
+			name:      "embedded field",
+			src:       "type Base struct{ Name string }\ntype Opts struct{ Base }\nfunc Task(o Opts) {}",
+			supported: false,
+		},
+		{
+			name:      "no exported fields",
+			src:       "type Opts struct{ name string }\nfunc Task(o Opts) {}",
+			supported: false,
+		},
+		{
+			name:      "pointer to options struct",
+			src:       "type Opts struct{ Name string }\nfunc Task(o *Opts) {}",
+			supported: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			funcs, err := parseSource("package main\n" + tt.src)
+			require.NoError(t, err)
+			assert.Equal(t, tt.supported, len(funcs) == 1)
+		})
+	}
+}
+
+func TestConvertOptionsStruct(t *testing.T) {
+	funk := function{
+		Name:    "Deploy",
+		Package: "ops",
+		Arguments: []argument{
+			{
+				Name: "o",
+				Type: "DeployOpts",
+				Fields: []argument{
+					{Name: "DryRun", Type: "bool", Long: "dry-run", Help: "only `show` it"},
+					{Name: "Env", Type: "Env", Long: "env", Text: true, Default: "staging", Env: "DEPLOY_ENV", Required: true},
+				},
+			},
+		},
+	}
+	cmd := convertToGoCmd(funk)
+	assert.Equal(t, "opsGadgets.DeployOpts", cmd.Options)
+	require.Len(t, cmd.GoFlags, 2)
+	assert.Equal(t, GoFlag{Type: "bool", Name: "dry-run", Field: "DryRun", Default: false, Help: "only 'show' it"}, cmd.GoFlags[0])
+	assert.Equal(t, GoFlag{Type: "opsGadgets.Env", Name: "env", Field: "Env", TextType: true, Default: "staging", HasDefault: true, Env: "DEPLOY_ENV", Required: true}, cmd.GoFlags[1])
+	assert.Equal(t, []string{`"env"`}, requiredFlags(cmd))
+	assert.Equal(t, "only 'show' it", flagUsage(cmd.GoFlags[0]))
+	assert.Equal(t, "(required)", flagUsage(cmd.GoFlags[1]))
+	assert.Equal(t, "DEPLOY_DRY_RUN", envVar(cmd.Name, cmd.GoFlags[0]))
+	assert.Equal(t, "DEPLOY_ENV", envVar(cmd.Name, cmd.GoFlags[1]))
+}
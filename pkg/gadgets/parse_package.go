@@ -0,0 +1,291 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// This file loads the gadgets as a type checked package, so what a function takes and returns is decided by
+// the types of its parameters and results, instead of how they're written. A `type Name = string` is a string,
+// a dot imported `Context` is the gogo.Context, and an interface that only embeds the gogo.Context is one too.
+//
+// The dependencies of the gadgets might not be in their go.mod yet, since it's only tidied once the main file
+// is rendered. The types from them can't be resolved until then, so the gogo.Context is still recognised by how
+// it's written when its type isn't known.
+
+// loadMode is what's loaded of the gadget package: its files, and the types of the packages it imports, which are
+// checked from their source, so the go command doesn't have to compile them
+const loadMode = packages.NeedName | packages.NeedCompiledGoFiles | packages.NeedImports | packages.NeedDeps |
+	packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo
+
+// checkedPackage is the package of the gadgets, with the types, definitions and uses of its expressions
+type checkedPackage struct {
+	fset       *token.FileSet
+	files      []*ast.File
+	pkg        *types.Package
+	info       *types.Info
+	syntax     scanner.ErrorList // the syntax errors in the files, which are parsed as far as they can be
+	typeErrors []types.Error     // the type errors in the files, which only leave the types of some expressions unknown
+}
+
+// loadPackage loads the files of a single package with go/packages, the way the binary is built from them,
+// which is only from the files given, like `go build -tags=gogo,mage <files>`. The dependencies come from
+// the package's module, and are type checked from their source. Only their declarations are needed, so the
+// bodies of their functions are skipped.
+func loadPackage(files []string) (*checkedPackage, error) {
+	checked := &checkedPackage{fset: token.NewFileSet()}
+	if len(files) == 0 {
+		checked.info = newTypesInfo()
+		return checked, nil
+	}
+	gadgets := map[string]bool{}
+	var patterns []string
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		// a main file left behind by an earlier build isn't one of the gadgets
+		if filepath.Base(abs) == MAIN_FILENAME {
+			continue
+		}
+		gadgets[abs] = true
+		patterns = append(patterns, abs)
+	}
+	var mu sync.Mutex
+	cfg := &packages.Config{
+		Mode: loadMode,
+		Dir:  filepath.Dir(files[0]),
+		// loading the files never changes their go.mod, even when GOFLAGS says it can
+		BuildFlags: []string{"-tags=gogo,mage", "-mod=readonly"},
+		Fset:       checked.fset,
+		ParseFile: func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+			if !gadgets[filename] {
+				file, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+				if file != nil {
+					for _, decl := range file.Decls {
+						if funcDecl, ok := decl.(*ast.FuncDecl); ok {
+							funcDecl.Body = nil
+						}
+					}
+				}
+				return file, err
+			}
+			file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+			var syntax scanner.ErrorList
+			if errors.As(err, &syntax) {
+				mu.Lock()
+				checked.syntax = append(checked.syntax, syntax...)
+				mu.Unlock()
+			}
+			return file, err
+		},
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %v: %w", filepath.Dir(files[0]), err)
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected the files to be a single package, found %d", len(pkgs))
+	}
+	pkg := pkgs[0]
+	checked.syntax.Sort()
+	checked.pkg, checked.info = pkg.Types, pkg.TypesInfo
+	for _, err := range pkg.TypeErrors {
+		// a dependency that isn't in the go.mod yet is added when it's tidied, before the binary is built
+		if strings.HasPrefix(err.Msg, "could not import ") {
+			continue
+		}
+		checked.typeErrors = append(checked.typeErrors, err)
+	}
+	if checked.info == nil {
+		checked.info = newTypesInfo()
+	}
+	for _, file := range pkg.Syntax {
+		if file != nil {
+			checked.files = append(checked.files, file)
+		}
+	}
+	return checked, nil
+}
+
+func newTypesInfo() *types.Info {
+	return &types.Info{
+		Types: map[ast.Expr]types.TypeAndValue{},
+		Defs:  map[*ast.Ident]types.Object{},
+		Uses:  map[*ast.Ident]types.Object{},
+	}
+}
+
+// typeOf returns the type of the expression, or nil when it isn't known, like a type from a dependency
+// that isn't in the go.mod yet
+func (c *checkedPackage) typeOf(expr ast.Expr) types.Type {
+	if c == nil || c.info == nil {
+		return nil
+	}
+	typ := c.info.TypeOf(expr)
+	if typ == nil || typ == types.Typ[types.Invalid] {
+		return nil
+	}
+	return typ
+}
+
+// fileOf returns the file the node is in
+func (c *checkedPackage) fileOf(node ast.Node) *ast.File {
+	if c == nil {
+		return nil
+	}
+	for _, file := range c.files {
+		if file.FileStart <= node.Pos() && node.Pos() <= file.FileEnd {
+			return file
+		}
+	}
+	return nil
+}
+
+// isGoGoContext checks if the parameter is a gogo.Context
+func (c *checkedPackage) isGoGoContext(param *ast.Field) bool {
+	if typ := c.typeOf(param.Type); typ != nil {
+		return isInterface(typ, GOGOIMPORTPATH, "Context")
+	}
+	alias := "gogo"
+	if file := c.fileOf(param); file != nil {
+		alias, _ = getGoGoImportName(file)
+	}
+	return isSelector(param.Type, alias, "Context")
+}
+
+// isStdContext checks if the parameter is a context.Context, which mage passes to any function that asks for one
+func (c *checkedPackage) isStdContext(param *ast.Field) bool {
+	if typ := c.typeOf(param.Type); typ != nil {
+		return isInterface(typ, "context", "Context")
+	}
+	return isSelector(param.Type, "context", "Context")
+}
+
+// isContext checks if the parameter is either context, which the gogo.Context is passed as
+func (c *checkedPackage) isContext(param *ast.Field) bool {
+	return c.isGoGoContext(param) || c.isStdContext(param)
+}
+
+// isInterface checks if the type is the named interface, or an interface that only embeds it
+func isInterface(typ types.Type, pkgPath, name string) bool {
+	if isNamed(typ, pkgPath, name) {
+		return true
+	}
+	iface, ok := typ.Underlying().(*types.Interface)
+	return ok && iface.NumExplicitMethods() == 0 && iface.NumEmbeddeds() == 1 && isInterface(iface.EmbeddedType(0), pkgPath, name)
+}
+
+// isNamed checks if the type is the named type declared in the package, or an alias of it
+func isNamed(typ types.Type, pkgPath, name string) bool {
+	named, ok := types.Unalias(typ).(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == pkgPath && named.Obj().Name() == name
+}
+
+// errorType is the built-in error interface
+var errorType = types.Universe.Lookup("error").Type()
+
+// isError checks if the expression is the error type, or an interface with the same methods
+func (c *checkedPackage) isError(expr ast.Expr) bool {
+	if typ := c.typeOf(expr); typ != nil {
+		return isErrorType(typ)
+	}
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "error"
+}
+
+func isErrorType(typ types.Type) bool {
+	return types.Identical(typ.Underlying(), errorType.Underlying())
+}
+
+// typeString returns how the type of the expression is written in the generated code, which is the type an
+// alias stands for, with the name of the package it's from, like `time.Duration` for a dot imported Duration.
+// The types declared with the gadgets are written without a package.
+func (c *checkedPackage) typeString(expr ast.Expr) string {
+	if ellipsis, ok := expr.(*ast.Ellipsis); ok {
+		return VARIADIC_PREFIX + c.typeString(ellipsis.Elt)
+	}
+	typ := c.typeOf(expr)
+	if typ == nil || !isResolved(typ) {
+		return exprToTypeStr(expr)
+	}
+	return types.TypeString(unalias(typ), func(pkg *types.Package) string {
+		if pkg == c.pkg {
+			return ""
+		}
+		return pkg.Name()
+	})
+}
+
+// unalias replaces the aliases in the type, including the ones of its elements, with the types they stand for
+func unalias(typ types.Type) types.Type {
+	switch t := types.Unalias(typ).(type) {
+	case *types.Slice:
+		return types.NewSlice(unalias(t.Elem()))
+	case *types.Array:
+		return types.NewArray(unalias(t.Elem()), t.Len())
+	case *types.Pointer:
+		return types.NewPointer(unalias(t.Elem()))
+	case *types.Map:
+		return types.NewMap(unalias(t.Key()), unalias(t.Elem()))
+	case *types.Chan:
+		return types.NewChan(t.Dir(), unalias(t.Elem()))
+	case *types.Named:
+		if t.TypeArgs().Len() == 0 {
+			return t
+		}
+		var args []types.Type
+		for i := range t.TypeArgs().Len() {
+			args = append(args, unalias(t.TypeArgs().At(i)))
+		}
+		if instance, err := types.Instantiate(nil, t.Origin(), args, false); err == nil {
+			return instance
+		}
+		return t
+	default:
+		return t
+	}
+}
+
+// isResolved checks that the type and its elements are all known
+func isResolved(typ types.Type) bool {
+	switch t := types.Unalias(typ).(type) {
+	case *types.Basic:
+		return t.Kind() != types.Invalid
+	case *types.Slice:
+		return isResolved(t.Elem())
+	case *types.Array:
+		return isResolved(t.Elem())
+	case *types.Pointer:
+		return isResolved(t.Elem())
+	case *types.Map:
+		return isResolved(t.Key()) && isResolved(t.Elem())
+	case *types.Chan:
+		return isResolved(t.Elem())
+	case *types.Named:
+		for i := range t.TypeArgs().Len() {
+			if !isResolved(t.TypeArgs().At(i)) {
+				return false
+			}
+		}
+	}
+	return true
+}
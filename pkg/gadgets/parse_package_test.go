@@ -0,0 +1,186 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+)
+
+// exportFiles are the compiled packages the test sources can import, by their import path. They're loaded from
+// the gogo module, since this module doesn't depend on it, and an empty file is a package that can't be imported.
+var exportFiles = struct {
+	sync.Mutex
+	files map[string]string
+}{files: map[string]string{}}
+
+// lookupExport opens the compiled package, loading it and its dependencies the first time it's imported
+func lookupExport(path string) (io.ReadCloser, error) {
+	exportFiles.Lock()
+	defer exportFiles.Unlock()
+	if _, found := exportFiles.files[path]; !found {
+		exportFiles.files[path] = ""
+		pkgs, err := packages.Load(&packages.Config{
+			Mode: packages.NeedName | packages.NeedImports | packages.NeedDeps | packages.NeedExportFile,
+			Dir:  filepath.Join("..", "gogo"),
+			// a package the gogo module doesn't depend on can't be imported, instead of being added to it
+			BuildFlags: []string{"-mod=readonly"},
+		}, path)
+		if err != nil {
+			return nil, err
+		}
+		packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+			if pkg.ExportFile != "" {
+				exportFiles.files[pkg.PkgPath] = pkg.ExportFile
+			}
+		})
+	}
+	if exportFiles.files[path] == "" {
+		return nil, fmt.Errorf("%s can't be imported by the test sources", path)
+	}
+	return os.Open(exportFiles.files[path])
+}
+
+// checkSources type checks the sources like loadPackage does with the files of a package, with the name
+// of the file each is from, which is used for the positions in errors. Syntax and type errors are kept like
+// loadPackage does, and the packages they import are loaded from their export data, since the sources aren't
+// in a module.
+func checkSources(names []string, srcs []string) *checkedPackage {
+	checked := &checkedPackage{fset: token.NewFileSet(), info: newTypesInfo()}
+	for i, src := range srcs {
+		file, err := parser.ParseFile(checked.fset, names[i], src, parser.ParseComments)
+		var syntax scanner.ErrorList
+		if errors.As(err, &syntax) {
+			checked.syntax = append(checked.syntax, syntax...)
+		} else if err != nil {
+			panic(err)
+		}
+		checked.files = append(checked.files, file)
+	}
+	conf := types.Config{
+		Importer: importer.ForCompiler(checked.fset, "gc", lookupExport),
+		Error: func(err error) {
+			if typeErr := err.(types.Error); !strings.HasPrefix(typeErr.Msg, "could not import ") {
+				checked.typeErrors = append(checked.typeErrors, typeErr)
+			}
+		},
+	}
+	checked.pkg, _ = conf.Check("gadgets", checked.fset, checked.files, checked.info)
+	return checked
+}
+
+func parseSource(src string) ([]function, error) {
+	return parseSources([]string{src})
+}
+
+// parseSources parses the sources of a single package together, since mage namespaces
+// and the Default and Aliases variables can be declared in a different file than the functions.
+func parseSources(srcs []string) ([]function, error) {
+	return parseNamedSources(make([]string, len(srcs)), srcs)
+}
+
+// parseNamedSources parses the sources like parseSources, with the name of the file each is from
+func parseNamedSources(names []string, srcs []string) ([]function, error) {
+	return parsePackage(checkSources(names, srcs))
+}
+
+func TestLoadPackage(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, src string) string {
+		file := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(file, []byte(src), 0o644))
+		return file
+	}
+	write("go.mod", "module gadgets\n\ngo 1.23\n")
+	typesFile := write("types.go", "package main\n\ntype Name = string\n\ntype Opts struct{ Env string }\n")
+	build := write("build.go", "package main\n\nimport \"context\"\n\nfunc Build(ctx context.Context, name Name) {}\n\nfunc Deploy(o Opts) {}\n")
+	// a main file left behind by an earlier build isn't loaded with the package
+	main := write(MAIN_FILENAME, "package main\n\nfunc main() {}\n\nfunc Main() {}\n")
+
+	funcs, err := parseAll([]string{typesFile, build, main})
+	require.NoError(t, err)
+	require.Len(t, funcs, 2)
+	assert.Equal(t, "Build", funcs[0].Name)
+	assert.True(t, funcs[0].UseGoGoCtx)
+	assert.Equal(t, []argument{{Name: "name", Type: "string"}}, funcs[0].Arguments)
+	assert.Equal(t, "Deploy", funcs[1].Name)
+	assert.Equal(t, []argument{{Name: "o", Type: "Opts", Fields: []argument{{Name: "Env", Type: "string", Long: "env"}}}}, funcs[1].Arguments)
+
+	// only the files given are loaded, so the types from the other files aren't known, which is reported
+	var reported bytes.Buffer
+	warnings = &reported
+	defer func() { warnings = os.Stderr }()
+	funcs, err = parseAll([]string{build})
+	require.NoError(t, err)
+	assert.Empty(t, funcs)
+	assert.Contains(t, reported.String(), "build.go:5:38: undefined: Name")
+
+	// the dependencies that aren't in the go.mod yet are added when it's tidied, so they aren't reported
+	reported.Reset()
+	_, err = parseAll([]string{write("deps.go", "package main\n\nimport \"github.com/google/uuid\"\n\nfunc ID() { _ = uuid.New() }\n")})
+	require.NoError(t, err)
+	assert.Empty(t, reported.String())
+
+	_, err = parseAll([]string{write("broken.go", "package main\n\nfunc Broken( {}\n")})
+	require.Error(t, err)
+}
+
+func TestSemanticTypes(t *testing.T) {
+	checked := checkSources([]string{"types.go"}, []string{fmt.Sprintf(`package gadgets
+		import (
+			"context"
+			g "%s"
+			t "time"
+		)
+		type Name = string
+		type Names = []Name
+		type Ctx interface{ g.Context }
+		type Failure interface{ error }
+		var (
+			name Name
+			names Names
+			wait t.Duration
+			ctx Ctx
+			stdCtx context.Context
+			failure Failure
+		)`, GOGOIMPORTPATH)})
+	exprs := map[string]ast.Expr{}
+	for _, decl := range checked.files[0].Decls {
+		if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.VAR {
+			for _, spec := range genDecl.Specs {
+				valueSpec := spec.(*ast.ValueSpec)
+				exprs[valueSpec.Names[0].Name] = valueSpec.Type
+			}
+		}
+	}
+	assert.Equal(t, "string", checked.typeString(exprs["name"]))
+	assert.Equal(t, "[]string", checked.typeString(exprs["names"]))
+	assert.Equal(t, "time.Duration", checked.typeString(exprs["wait"]))
+	assert.True(t, checked.isGoGoContext(&ast.Field{Type: exprs["ctx"]}))
+	assert.False(t, checked.isStdContext(&ast.Field{Type: exprs["ctx"]}))
+	assert.True(t, checked.isStdContext(&ast.Field{Type: exprs["stdCtx"]}))
+	assert.True(t, checked.isError(exprs["failure"]))
+	assert.False(t, checked.isError(exprs["name"]))
+}
@@ -0,0 +1,122 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+)
+
+// This file makes the exported methods of a task struct into a command group, like the methods of a mage
+// namespace. A task struct is an exported struct declared with the gadgets, that has exported methods:
+//
+//	// Docker builds and runs the containers
+//	type Docker struct{}
+//
+//	// Build builds the image
+//	func (Docker) Build(tag string) error
+//
+// Build is run as `docker:build`, on a new zero value of Docker, and the doc comment of Docker describes
+// the group. A struct that a function takes its options as, or that's an argument type because it implements
+// encoding.TextUnmarshaler, isn't a task struct. The exported methods of those are reported, since they're skipped.
+
+// conversionMethods are the methods an argument type has to be converted to and from text, which aren't reported
+// when they're skipped
+var conversionMethods = []string{"UnmarshalText", "MarshalText", "String"}
+
+// findTaskTypes adds the task structs declared in the files to the namespaces, with the short description
+// from their doc comment, and reports the exported methods of the structs that aren't task structs
+func findTaskTypes(files []*ast.File, pkgTypes packageTypes, namespaces map[string]string, diags *diagnostics) {
+	methods := exportedMethods(files)
+	params := optionsParams(files, pkgTypes)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				name := typeSpec.Name.Name
+				if _, isStruct := typeSpec.Type.(*ast.StructType); !isStruct || !typeSpec.Name.IsExported() ||
+					typeSpec.TypeParams != nil || len(methods[name]) == 0 {
+					continue
+				}
+				var reason string
+				if isTextType(typeSpec, pkgTypes) {
+					reason = fmt.Sprintf("%s implements encoding.TextUnmarshaler, so it's an argument type rather than a command group", name)
+				} else if params[name] {
+					reason = fmt.Sprintf("%s is an options struct, so it isn't a command group", name)
+				}
+				if reason != "" {
+					for _, method := range methods[name] {
+						if !slices.Contains(conversionMethods, method.Name.Name) {
+							diags.report(method.Name.Pos(), method.Name.Name, CATEGORY_METHOD, reason)
+						}
+					}
+					continue
+				}
+				if _, found := namespaces[name]; !found {
+					namespaces[name] = parseDoc(typeDoc(genDecl, typeSpec)).short
+				}
+			}
+		}
+	}
+}
+
+// isTextType checks if the struct implements encoding.TextUnmarshaler, whether or not it's an argument yet
+func isTextType(typeSpec *ast.TypeSpec, pkgTypes packageTypes) bool {
+	if _, isText := pkgTypes.text[typeSpec.Name.Name]; isText {
+		return true
+	}
+	obj := pkgTypes.checked.info.Defs[typeSpec.Name]
+	return obj != nil && types.Implements(types.NewPointer(obj.Type()), textUnmarshaler)
+}
+
+// optionsParams returns the structs the exported functions and methods take their options as
+func optionsParams(files []*ast.File, pkgTypes packageTypes) map[string]bool {
+	params := map[string]bool{}
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || !funcDecl.Name.IsExported() || funcDecl.Type.Params == nil {
+				continue
+			}
+			for _, param := range funcDecl.Type.Params.List {
+				if isOptionsParam(param, pkgTypes) {
+					params[pkgTypes.checked.typeString(param.Type)] = true
+				}
+			}
+		}
+	}
+	return params
+}
+
+// exportedMethods returns the exported methods of each type, on its value or a pointer to it
+func exportedMethods(files []*ast.File) map[string][]*ast.FuncDecl {
+	methods := map[string][]*ast.FuncDecl{}
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Recv == nil || len(funcDecl.Recv.List) != 1 || !funcDecl.Name.IsExported() {
+				continue
+			}
+			recv := funcDecl.Recv.List[0].Type
+			if star, ok := recv.(*ast.StarExpr); ok {
+				recv = star.X
+			}
+			if ident, ok := recv.(*ast.Ident); ok {
+				methods[ident.Name] = append(methods[ident.Name], funcDecl)
+			}
+		}
+	}
+	return methods
+}
@@ -0,0 +1,146 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTaskStructs(t *testing.T) {
+	funcs, err := parseSources([]string{
+		`package main
+
+// Docker builds and runs the containers.
+// It's a task struct.
+type Docker struct {
+	image string
+}
+
+// Build builds the image
+func (Docker) Build(tag string) error { return nil }
+
+// Up starts the containers
+func (d *Docker) Up() {}
+
+func (Docker) helper() {}
+
+type (
+	// DB manages the database
+	DB struct{}
+)
+
+func (DB) Migrate() {}
+
+var Default = Docker.Build`,
+		`package main
+
+// Env is an argument type, not a task struct
+type Env struct{ name string }
+
+func (e *Env) UnmarshalText(text []byte) error { return nil }
+
+func (e Env) Name() string { return e.name }
+
+func Ping(env Env) {}
+
+// tasks isn't exported
+type tasks struct{}
+
+func (tasks) Run() {}
+
+// Name isn't a struct
+type Name string
+
+func (Name) Print() {}
+
+// DeployOpts are the options of Deploy, which aren't a group, even though they have methods
+type DeployOpts struct {
+	Env string
+}
+
+func (o DeployOpts) Validate() error { return nil }
+
+func (o DeployOpts) String() string { return o.Env }
+
+func Deploy(o DeployOpts) error { return o.Validate() }
+
+// Level implements encoding.TextUnmarshaler, so it isn't a task struct, even before it's an argument
+type Level struct{ value int }
+
+func (l *Level) UnmarshalText(text []byte) error { return nil }
+
+func (l Level) Raise() {}
+
+// Version has fields that could be options, but a function doesn't take it as them
+type Version struct{ Major int }
+
+func (Version) Print() {}`,
+	})
+	require.NoError(t, err)
+	var names []string
+	for _, f := range funcs {
+		names = append(names, f.QualifiedName())
+	}
+	assert.ElementsMatch(t, []string{"docker:Build", "docker:Up", "db:Migrate", "Ping", "Deploy", "version:Print"}, names)
+
+	for _, f := range funcs {
+		switch f.Name {
+		case "Build":
+			assert.Equal(t, "Docker", f.Receiver)
+			assert.Equal(t, "Docker builds and runs the containers.", f.GroupDescription)
+			assert.True(t, f.Default)
+			assert.Equal(t, []argument{{Name: "tag", Type: "string"}}, f.Arguments)
+		case "Up":
+			assert.Equal(t, "Docker", f.Receiver)
+		case "Migrate":
+			assert.Equal(t, "DB manages the database", f.GroupDescription)
+		}
+	}
+
+	rds, err := convertToGoCmds(funcs)
+	require.NoError(t, err)
+	require.Len(t, rds, 1)
+	shorts := map[string]string{}
+	for _, cmd := range rds[0].SubCommands {
+		shorts[cmd.Name] = cmd.Short
+	}
+	assert.Equal(t, map[string]string{"docker": "Docker builds and runs the containers.", "db": "DB manages the database", "Ping": "", "Deploy": "",
+		"version": "Version has fields that could be options, but a function doesn't take it as them"}, shorts)
+
+	// the exported methods of the structs that aren't task structs are reported
+	checked := checkSources([]string{"main.go"}, []string{`package main
+
+type Env struct{ name string }
+
+func (e *Env) UnmarshalText(text []byte) error { return nil }
+
+func (e Env) String() string { return e.name }
+
+func (e Env) Name() string { return e.name }
+
+func Ping(env Env) {}
+
+type DeployOpts struct{ Env string }
+
+func (o DeployOpts) Validate() error { return nil }
+
+func Deploy(o DeployOpts) error { return o.Validate() }`})
+	_, diags, err := analyzePackage(checked)
+	require.NoError(t, err)
+	var got []string
+	for _, diag := range diags {
+		got = append(got, diag.String())
+	}
+	assert.Equal(t, []string{
+		"main.go:9:14: Name: Env implements encoding.TextUnmarshaler, so it's an argument type rather than a command group",
+		"main.go:15:21: Validate: DeployOpts is an options struct, so it isn't a command group",
+	}, got)
+}
@@ -0,0 +1,106 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// This file finds the argument types that implement encoding.TextUnmarshaler, which the generated
+// binary parses with their UnmarshalText method. Unlike the other argument types, these can't be
+// recognised by their name, so they're found by the methods of their type.
+
+// textType is an argument type that implements encoding.TextUnmarshaler
+type textType struct {
+	Import string // the import path of the package the type is declared in, or empty if it is declared with the gadgets
+}
+
+// textUnmarshaler is the encoding.TextUnmarshaler interface
+var textUnmarshaler = types.NewInterfaceType([]*types.Func{
+	types.NewFunc(token.NoPos, nil, "UnmarshalText", types.NewSignatureType(nil, nil, nil,
+		types.NewTuple(types.NewVar(token.NoPos, nil, "text", types.NewSlice(types.Typ[types.Byte]))),
+		types.NewTuple(types.NewVar(token.NoPos, nil, "", types.Universe.Lookup("error").Type())),
+		false)),
+}, nil).Complete()
+
+// findTextTypes returns the argument types of the functions, and of the fields of their options structs,
+// that implement encoding.TextUnmarshaler, keyed by how the type is written in the generated code.
+func findTextTypes(checked *checkedPackage, structs map[string]*ast.StructType) map[string]textType {
+	candidates := textTypeCandidates(checked, structs)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	found := map[string]textType{}
+	for _, expr := range candidates {
+		typ := checked.typeOf(expr)
+		if typ == nil {
+			continue
+		}
+		if !types.Implements(types.NewPointer(typ), textUnmarshaler) {
+			continue
+		}
+		var importPath string
+		if named, ok := types.Unalias(typ).(*types.Named); ok && named.Obj().Pkg() != nil && named.Obj().Pkg() != checked.pkg {
+			importPath = named.Obj().Pkg().Path()
+		}
+		found[checked.typeString(expr)] = textType{Import: importPath}
+	}
+	return found
+}
+
+// textTypeCandidates returns the argument types of the functions that could be a type implementing
+// encoding.TextUnmarshaler, which are the named types that aren't otherwise supported.
+// When an argument is a struct, the types of its fields are candidates too, in case it's an options struct.
+func textTypeCandidates(checked *checkedPackage, structs map[string]*ast.StructType) []ast.Expr {
+	var candidates []ast.Expr
+	for _, file := range checked.files {
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || !funcDecl.Name.IsExported() || funcDecl.Type.Params == nil {
+				continue
+			}
+			for _, param := range funcDecl.Type.Params.List {
+				if checked.isContext(param) {
+					continue
+				}
+				switch param.Type.(type) {
+				case *ast.Ident, *ast.SelectorExpr:
+				default:
+					continue
+				}
+				typ := checked.typeString(param.Type)
+				if _, found := lookupArgType(typ); !found {
+					candidates = append(candidates, param.Type)
+				}
+				if structs[typ] != nil {
+					candidates = append(candidates, fieldCandidates(checked, structs[typ])...)
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+// fieldCandidates returns the types of the struct's fields that could be a type implementing encoding.TextUnmarshaler
+func fieldCandidates(checked *checkedPackage, structType *ast.StructType) []ast.Expr {
+	var candidates []ast.Expr
+	for _, field := range structType.Fields.List {
+		switch field.Type.(type) {
+		case *ast.Ident, *ast.SelectorExpr:
+		default:
+			continue
+		}
+		if _, found := lookupArgType(checked.typeString(field.Type)); !found {
+			candidates = append(candidates, field.Type)
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,117 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strconv"
+	"time"
+)
+
+// This file resolves the arguments passed to the context methods, like ctx.Argument(x).Default(DefaultRegion),
+// to the values they stand for. From the types of the package, constant expressions, typed constants and
+// values like `time.Second*30` are evaluated, and a package-level variable is resolved to its initial value.
+
+// packageValues evaluates the expressions in a package that are constant, or are initialized with one
+type packageValues struct {
+	checked *checkedPackage
+	inits   map[types.Object]ast.Expr // the expression each package-level variable is initialized with
+}
+
+// value returns the value of the expression written the way a literal of it would be, like the other
+// arguments of the context methods. Strings are unquoted, and durations are written like "30s".
+func (v *packageValues) value(expr ast.Expr) (string, bool) {
+	if v == nil || v.checked == nil {
+		return "", false
+	}
+	info := v.checked.info
+	if tv, found := info.Types[expr]; found && tv.Value != nil {
+		return constantText(tv.Type, tv.Value), true
+	}
+	// a package-level variable has the value it's initialized with, if that is a constant
+	ident, ok := ast.Unparen(expr).(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	obj, ok := info.Uses[ident].(*types.Var)
+	if !ok || obj.Pkg() == nil || obj.Parent() != obj.Pkg().Scope() {
+		return "", false
+	}
+	init, found := v.variableInits()[obj]
+	if !found {
+		return "", false
+	}
+	if tv, found := info.Types[init]; found && tv.Value != nil {
+		return constantText(obj.Type(), tv.Value), true
+	}
+	return "", false
+}
+
+// variableInits finds the expression each package-level variable is initialized with
+func (v *packageValues) variableInits() map[types.Object]ast.Expr {
+	if v.inits != nil {
+		return v.inits
+	}
+	info := v.checked.info
+	v.inits = map[types.Object]ast.Expr{}
+	for _, file := range v.checked.files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				valueSpec := spec.(*ast.ValueSpec)
+				// `var a, b = f()` assigns several variables from a single call, which isn't a constant
+				if len(valueSpec.Values) != len(valueSpec.Names) {
+					continue
+				}
+				for i, name := range valueSpec.Names {
+					if obj := info.Defs[name]; obj != nil {
+						v.inits[obj] = valueSpec.Values[i]
+					}
+				}
+			}
+		}
+	}
+	return v.inits
+}
+
+// constantText writes the constant the way it's written as a literal, and as the flag parses it
+func constantText(typ types.Type, value constant.Value) string {
+	switch value.Kind() {
+	case constant.String:
+		return constant.StringVal(value)
+	case constant.Bool:
+		return strconv.FormatBool(constant.BoolVal(value))
+	case constant.Float:
+		f, _ := constant.Float64Val(value)
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	case constant.Int:
+		if isDurationType(typ) {
+			if i, exact := constant.Int64Val(value); exact {
+				return time.Duration(i).String()
+			}
+		}
+		// a rune is written as a character, which ctx.Argument(x).Short expects, and is passed to it as a byte
+		if basic, ok := typ.(*types.Basic); ok && (basic.Kind() == types.UntypedRune || basic.Name() == "rune" || basic.Name() == "byte") {
+			if i, exact := constant.Int64Val(value); exact {
+				return strconv.QuoteRune(rune(i))
+			}
+		}
+	}
+	return value.ExactString()
+}
+
+func isDurationType(typ types.Type) bool {
+	named, ok := types.Unalias(typ).(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Duration"
+}
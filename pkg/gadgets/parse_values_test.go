@@ -0,0 +1,64 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseContextValues(t *testing.T) {
+	funcs, err := parseSource(fmt.Sprintf(`package main
+import (
+	"time"
+	"%s"
+)
+
+type Env string
+
+const (
+	EnvDev  Env = "dev"
+	EnvProd Env = "prod"
+)
+
+const (
+	DefaultRegion = "us-" + "east-1"
+	RegionFlag    = 'r'
+	MaxReplicas   = 2 * 5
+	Ratio         = 1.5
+)
+
+var fallback = EnvDev
+
+func region() string { return "eu-west-1" }
+
+func Deploy(ctx gogo.Context, region string, env string, replicas int, timeout time.Duration, ratio float64, zone string) {
+	ctx.Argument(region).Default(DefaultRegion).Short(RegionFlag)
+	ctx.Argument(env).Default(fallback).AllowedValues(EnvDev, EnvProd)
+	ctx.Argument(replicas).AllowedValues(1, MaxReplicas/2, MaxReplicas).RestrictedValues(-1)
+	ctx.Argument(timeout).Default(time.Second * 30)
+	ctx.Argument(ratio).Default(Ratio)
+	ctx.Argument(zone).Default(region())
+}`, GOGOIMPORTPATH))
+	require.NoError(t, err)
+	require.Len(t, funcs, 1)
+
+	expected := []argument{
+		{Name: "region", Type: "string", Default: "us-east-1", Short: 'r'},
+		{Name: "env", Type: "string", Default: "dev", AllowedValues: []string{"dev", "prod"}},
+		{Name: "replicas", Type: "int", AllowedValues: []string{"1", "5", "10"}, RestrictedValues: []string{"-1"}},
+		{Name: "timeout", Type: "time.Duration", Default: "30s"},
+		{Name: "ratio", Type: "float64", Default: "1.5"},
+		// a value that's only known when the gadget runs is left as it's written
+		{Name: "zone", Type: "string", Default: "region()"},
+	}
+	assert.Equal(t, expected, funcs[0].Arguments)
+}
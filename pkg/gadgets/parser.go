@@ -8,11 +8,12 @@
 package gadgets
 
 import (
+	"errors"
 	"fmt"
 	"go/ast"
-	"go/parser"
 	"go/token"
 	"go/types"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
@@ -27,7 +28,16 @@ type function struct {
 	Arguments           []argument
 	UseGoGoCtx          bool
 	GoGoCtxVariableName string
-	ErrorReturn         bool // does the function return an error?
+	ErrorReturn         bool     // does the function return an error?
+	ReturnType          string   // the type of the value the function returns, which is written with --output, if any
+	Stream              string   // how the returned value streams its elements, when it is an iter.Seq, iter.Seq2 or channel
+	Group               string   // the command group, from the subpackage or mage namespace the function is in
+	Package             string   // the subpackage of the gogo folder the function is in, as a command group
+	Receiver            string   // the mage namespace or task struct the function is a method of
+	GroupDescription    string   // the description of the group, from the doc comment of the receiver's type
+	Default             bool     // is this the function run when no function is given?
+	Aliases             []string // other names the function can be run with
+	Hidden              bool     // is the function left out of the list and help, while still being run by name?
 }
 
 type argument struct {
@@ -37,17 +47,44 @@ type argument struct {
 	Short            byte   // The short character for the argument
 	Description      string // short description of the argument
 	Help             string
-	Default          any
-	AllowedValues    []any
-	RestrictedValues []any
+	Default          string      // the default value, written like it's given as a flag, or "" when there's none
+	AllowedValues    []string    // the values the argument can have, written like they're given as a flag
+	RestrictedValues []string    // the values the argument can't have, written like they're given as a flag
+	Text             bool        // the type implements encoding.TextUnmarshaler, and is parsed with it
+	TypeImport       string      // the import path of the package the type is from, when it is imported
+	Env              string      // the environment variable the argument is read from, when it is a field of an options struct
+	Fields           []argument  // the fields of the options struct, when the argument is one
+	Variadic         bool        // the argument is the variadic parameter, which takes the remaining positional arguments
+	Underlying       string      // the scalar type an enum type is declared as, which it's parsed as, unless it implements encoding.TextUnmarshaler
+	Enum             []enumValue // the values of the enum type the argument has, which it's allowed to have unless ctx.Argument(x).AllowedValues narrows them
+	Required         bool        // the argument must be given, as a flag, with its environment variable or as a positional argument
 }
 
 const GOGOIMPORTPATH = "github.com/2bit-software/gogo/pkg/gogo"
 
+// HIDDEN_DIRECTIVE in the doc comment of a function hides it, like calling ctx.Hidden()
+const HIDDEN_DIRECTIVE = "//gogo:hidden"
+
+// The ways a returned value can stream its elements, named after the gogo function that writes them
+const (
+	STREAM_SEQ  = "Seq"  // iter.Seq[T]
+	STREAM_SEQ2 = "Seq2" // iter.Seq2[T, error]
+	STREAM_CHAN = "Chan" // <-chan T
+)
+
 // parseDirectory reads in a list of files and extracts the function information, aggregating it into a single list
 // TODO: this might need to return a map of files/functions instead
 func parseDirectory(dir string) ([]function, error) {
-	var functions []function
+	files, err := directoryFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	return parseAll(files)
+}
+
+// directoryFiles returns the .go files of the gadgets in the directory
+func directoryFiles(dir string) ([]string, error) {
+	var files []string
 	items, err := os.ReadDir(dir)
 	if err != nil {
 		return nil, err
@@ -69,50 +106,114 @@ func parseDirectory(dir string) ([]function, error) {
 		if item.Name() == MAIN_FILENAME {
 			continue
 		}
-		funcs, err := parse(path.Join(dir, item.Name()))
-		if err != nil {
-			return nil, err
-		}
-		functions = append(functions, funcs...)
+		files = append(files, path.Join(dir, item.Name()))
 	}
-	return functions, nil
+	return files, nil
 }
 
+// parseAll reads in the files of a single package, and extracts the function information
 func parseAll(files []string) ([]function, error) {
-	var functions []function
-	for _, file := range files {
-		funcs, err := parse(file)
-		if err != nil {
-			return nil, err
-		}
-		functions = append(functions, funcs...)
+	checked, err := loadPackage(files)
+	if err != nil {
+		return nil, err
+	}
+	if err := checked.syntax.Err(); err != nil {
+		return nil, err
 	}
-	return functions, nil
+	return parsePackage(checked)
 }
 
 // parse reads in a source document and extracts the function information
 func parse(filename string) ([]function, error) {
-	// read the file
-	f, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, err
+	return parseAll([]string{filename})
+}
+
+// warnings is where the type errors and invalid uses of the context are reported while parsing, which is stderr, so they don't
+// mix with the functions that are listed, or the output of the function that's run
+var warnings io.Writer = os.Stderr
+
+// parsePackage extracts the function information from the files of the package. The functions that aren't
+// gadgets are skipped, and type errors and invalid uses of the context are reported and ignored.
+func parsePackage(checked *checkedPackage) ([]function, error) {
+	functions, diags, err := analyzePackage(checked)
+	for _, diag := range diags {
+		if diag.Category == CATEGORY_CONTEXT || diag.Category == CATEGORY_TYPE {
+			_, _ = fmt.Fprintf(warnings, "warning: %v\n", diag)
+		}
 	}
-	// parse the source
-	return parseSource(string(f))
+	return functions, err
 }
 
-func parseSource(src string) ([]function, error) {
-	// Parse the source code
-	fset := token.NewFileSet()
-	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+// analyzePackage extracts the function information from the files of the package together, since mage namespaces,
+// the Default and Aliases variables, and the types of the arguments can be declared in a different file than the functions.
+// Along with the functions, it returns the diagnostics explaining why the other exported functions aren't gadgets.
+func analyzePackage(checked *checkedPackage) ([]function, []diagnostic, error) {
+	diags := &diagnostics{fset: checked.fset}
+	for _, err := range checked.syntax {
+		diags.list = append(diags.list, diagnostic{Position: err.Pos, Category: CATEGORY_SYNTAX, Message: err.Msg})
+	}
+	// the type errors in a file that doesn't parse mostly follow from the syntax errors
+	if len(checked.syntax) == 0 {
+		for _, err := range checked.typeErrors {
+			diags.report(err.Pos, "", CATEGORY_TYPE, err.Msg)
+		}
+	}
+	structs := findStructTypes(checked.files)
+	pkgTypes := packageTypes{
+		checked: checked,
+		text:    findTextTypes(checked, structs),
+		enums:   findEnumTypes(checked, structs),
+		values:  &packageValues{checked: checked},
+	}
+	pkgTypes.options = findOptionStructs(structs, pkgTypes)
+	namespaces := findNamespaces(checked.files)
+	findTaskTypes(checked.files, pkgTypes, namespaces, diags)
+	var functions []function
+	var errs []error
+	for _, file := range checked.files {
+		fileFunctions, err := parseFile(checked.fset, file, namespaces, pkgTypes, diags)
+		errs = append(errs, err)
+		functions = append(functions, fileFunctions...)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, diags.list, err
+	}
+
+	defaultTarget, aliases, err := findMageTargets(checked.files, namespaces)
 	if err != nil {
-		panic(err)
+		return nil, diags.list, err
+	}
+	applyMageTargets(functions, defaultTarget, aliases)
+	if err := checkAliases(functions); err != nil {
+		return nil, diags.list, err
 	}
+	return functions, diags.list, nil
+}
 
-	// Find the import alias for the gogo package
-	gogoAlias, _ := getGoGoImportName(file)
+// checkAliases ensures each alias refers to a single function, and doesn't hide the name of another function
+func checkAliases(funcs []function) error {
+	owners := map[string]string{}
+	for _, f := range funcs {
+		for _, alias := range f.Aliases {
+			if owner, found := owners[alias]; found && owner != f.QualifiedName() {
+				return fmt.Errorf("alias %q is used by both %s and %s", alias, owner, f.QualifiedName())
+			}
+			owners[alias] = f.QualifiedName()
+		}
+	}
+	for _, f := range funcs {
+		if owner, found := owners[f.QualifiedName()]; found && owner != f.QualifiedName() {
+			return fmt.Errorf("alias %q of %s is the name of another function", f.QualifiedName(), owner)
+		}
+	}
+	return nil
+}
 
+// parseFile extracts the function information from a single parsed file, reporting why the exported functions
+// that aren't gadgets are skipped
+func parseFile(fset *token.FileSet, file *ast.File, namespaces map[string]string, pkgTypes packageTypes, diags *diagnostics) ([]function, error) {
 	var functions []function
+	var errs []error
 	// For each function, extract the information
 	ast.Inspect(file, func(node ast.Node) bool {
 		funcDecl, ok := node.(*ast.FuncDecl)
@@ -123,16 +224,14 @@ func parseSource(src string) ([]function, error) {
 		if !funcDecl.Name.IsExported() {
 			return true
 		}
-		// check if all the arguments are acceptable
-		if !acceptableArguments(gogoAlias, funcDecl) {
+		// methods are only run when they are on a mage namespace or task struct
+		namespace, isNamespaced := receiverNamespace(funcDecl, namespaces)
+		if funcDecl.Recv != nil && !isNamespaced {
 			return true
 		}
-		// check if the function has an acceptable return type
-		if !acceptableReturnTypes(funcDecl) {
-			return true
-		}
-
-		if !gogoContextCorrectPosition(gogoAlias, funcDecl) {
+		// check if the arguments and return types are acceptable, and the context is in the right position
+		if problem := signatureProblem(funcDecl, pkgTypes); problem != nil {
+			diags.report(problem.node.Pos(), funcDecl.Name.Name, CATEGORY_SIGNATURE, problem.message)
 			return true
 		}
 
@@ -146,117 +245,224 @@ func parseSource(src string) ([]function, error) {
 		defer func(pCtx *function) {
 			functions = append(functions, *pCtx)
 		}(pCtx)
+		if isNamespaced {
+			pCtx.Group = namespaceGroup(namespace)
+			pCtx.GroupDescription = namespaces[namespace]
+			pCtx.Receiver = namespace
+		}
 		// fill out the arg
-		pCtx = gatherDetails(pCtx, gogoAlias, funcDecl)
+		if _, err := gatherDetails(fset, pCtx, funcDecl, pkgTypes); err != nil {
+			// a property set to two different values is an error in the gadget, like an alias used twice
+			var conflict *conflictError
+			if errors.As(err, &conflict) {
+				diags.report(conflict.pos, pCtx.Name, CATEGORY_CONFLICT, conflict.describe())
+				errs = append(errs, err)
+			} else {
+				diags.report(funcDecl.Name.Pos(), pCtx.Name, CATEGORY_CONTEXT, err.Error())
+			}
+		}
 
 		// continue parsing the rest of the functions
 		return true
 	})
 
-	return functions, nil
+	return functions, errors.Join(errs...)
+}
+
+// problem is why a function isn't a gadget, at the part of it that's wrong
+type problem struct {
+	node    ast.Node
+	message string
+}
+
+func newProblem(node ast.Node, format string, args ...any) *problem {
+	return &problem{node: node, message: fmt.Sprintf(format, args...)}
 }
 
-// gogoContextCorrectPosition checks if the gogo.Context is in the correct position
+// signatureProblem checks that the arguments and the return types are acceptable, and that the context is
+// in the right position, returning the first problem with the signature
+func signatureProblem(funcDecl *ast.FuncDecl, pkgTypes packageTypes) *problem {
+	if problem := argumentsProblem(funcDecl, pkgTypes); problem != nil {
+		return problem
+	}
+	if problem := returnTypesProblem(funcDecl, pkgTypes.checked); problem != nil {
+		return problem
+	}
+	return contextPositionProblem(funcDecl, pkgTypes.checked)
+}
+
+// contextPositionProblem checks if the gogo.Context is in the correct position
 // If the function has a gogo.Context, it must be the first argument
-func gogoContextCorrectPosition(alias string, decl *ast.FuncDecl) bool {
+func contextPositionProblem(decl *ast.FuncDecl, checked *checkedPackage) *problem {
 	for i, param := range decl.Type.Params.List {
-		if isGoGoCtx(alias, param) && i == 0 {
+		if i == 0 {
 			continue
 		}
-		if isGoGoCtx(alias, param) {
-			return false
+		if checked.isGoGoContext(param) {
+			return newProblem(param, "gogo.Context must be the first parameter")
+		}
+		if checked.isStdContext(param) {
+			return newProblem(param, "context.Context must be the first parameter")
 		}
 	}
-	return true
+	return nil
 }
 
-// acceptableReturnTypes checks if the function has an acceptable return type,
-// which is either nothing or an error
-func acceptableReturnTypes(funcDecl *ast.FuncDecl) bool {
-	if funcDecl == nil {
-		return false
-	}
-	if funcDecl.Type == nil {
-		return false
+// returnTypesProblem checks if the function has an acceptable return type,
+// which is either nothing, an error, a value, or a value and an error. The value can be a stream.
+func returnTypesProblem(funcDecl *ast.FuncDecl, checked *checkedPackage) *problem {
+	results := resultTypes(funcDecl)
+	switch len(results) {
+	case 0:
+		return nil
+	case 1:
+		if checked.isError(results[0]) || isValueType(checked.resultType(results[0])) {
+			return nil
+		}
+	case 2:
+		if !checked.isError(results[1]) {
+			return newProblem(results[1], "the second return value has type %s, but only an error can be returned after a value", checked.typeString(results[1]))
+		}
+		if isValueType(checked.resultType(results[0])) {
+			return nil
+		}
+	default:
+		return newProblem(funcDecl.Type.Results, "returns %d values, but a gadget can only return a value and an error", len(results))
 	}
+	return newProblem(results[0], "return type %s can't be written as output", checked.typeString(results[0]))
+}
+
+// resultTypes returns the type of each of the values the function returns
+func resultTypes(funcDecl *ast.FuncDecl) []ast.Expr {
 	if funcDecl.Type.Results == nil {
-		return true
+		return nil
 	}
-	// Check if the return value is either empty
-	if len(funcDecl.Type.Results.List) == 0 {
-		return true
+	var results []ast.Expr
+	for _, field := range funcDecl.Type.Results.List {
+		// named results, like `(a, b int)`, share a field
+		for range max(len(field.Names), 1) {
+			results = append(results, field.Type)
+		}
 	}
-	// or more than one value
-	if len(funcDecl.Type.Results.List) > 1 {
-		return false
+	return results
+}
+
+// resultType returns the type of a value the function returns. A type that isn't known, like one from a
+// dependency that isn't in the go.mod yet, is invalid, which is treated like any other value.
+func (c *checkedPackage) resultType(expr ast.Expr) types.Type {
+	if typ := c.typeOf(expr); typ != nil {
+		return typ
 	}
-	return hasErrorReturn(funcDecl)
+	return types.Typ[types.Invalid]
 }
 
-// acceptableArguments checks to make sure that all the arguments are scalar types, except
-// for the gogo.Context, if it exists
-func acceptableArguments(alias string, funcDecl *ast.FuncDecl) bool {
-	if funcDecl == nil {
-		return false
+// isValueType determines if a function can return a value of the type, to be written with --output.
+// Functions and channels can't be written, and neither can an error, which is returned as the error instead.
+// Streams are written an element at a time, so their elements have to be values.
+func isValueType(typ types.Type) bool {
+	if elem, isStream := streamElem(typ); isStream {
+		return isValueType(elem)
 	}
-	if funcDecl.Type == nil {
+	switch t := typ.Underlying().(type) {
+	case *types.Signature, *types.Chan:
 		return false
+	case *types.Pointer:
+		return isValueType(t.Elem())
 	}
-	// no args, so it's fine
-	if funcDecl.Type.Params == nil {
-		return true
-	}
-	for _, param := range funcDecl.Type.Params.List {
-		if isGoGoCtx(alias, param) {
-			continue
+	return !isErrorType(typ)
+}
+
+// streamKind returns how a value of the type streams its elements, or an empty string if it doesn't.
+// Only a receive-only channel streams, and an iter.Seq2 has to yield an error after each element.
+func streamKind(typ types.Type) string {
+	switch t := types.Unalias(typ).(type) {
+	case *types.Chan:
+		if t.Dir() == types.RecvOnly {
+			return STREAM_CHAN
 		}
-		if !isScalarType(param) {
-			return false
+	case *types.Named:
+		if isNamed(t, "iter", "Seq") {
+			return STREAM_SEQ
 		}
-		// check if the type is a pointer (we don't allow pointers)
-		if starExpr, ok := param.Type.(*ast.StarExpr); ok {
-			if starExpr != nil {
-				return false
-			}
+		if isNamed(t, "iter", "Seq2") && t.TypeArgs().Len() == 2 && isErrorType(t.TypeArgs().At(1)) {
+			return STREAM_SEQ2
 		}
 	}
-	return true
+	return ""
 }
 
-// isScalarType checks if the given parameter is a scalar type
-func isScalarType(param *ast.Field) bool {
-	if param == nil || param.Type == nil {
-		return false
+// streamElem returns the type of the elements a stream yields, if the type is one
+func streamElem(typ types.Type) (types.Type, bool) {
+	switch streamKind(typ) {
+	case STREAM_CHAN:
+		return types.Unalias(typ).(*types.Chan).Elem(), true
+	case STREAM_SEQ, STREAM_SEQ2:
+		return types.Unalias(typ).(*types.Named).TypeArgs().At(0), true
 	}
+	return nil, false
+}
 
-	// Identify the type node
-	ident, ok := param.Type.(*ast.Ident)
-	if !ok {
-		return false
+// returnType returns the type of the value the function returns, or an empty string if it only returns an error
+func returnType(funcDecl *ast.FuncDecl, checked *checkedPackage) string {
+	results := resultTypes(funcDecl)
+	if len(results) == 0 || checked.isError(results[0]) {
+		return ""
 	}
+	return checked.typeString(results[0])
+}
 
-	// Check if it's one of our defined scalar types
-	switch ident.Name {
-	case "string", "int", "float64", "bool":
-		return true
-	default:
-		return false
+// argumentsProblem checks to make sure that all the arguments are supported types, except
+// for the gogo.Context, if it exists. An options struct has to be the only other argument.
+func argumentsProblem(funcDecl *ast.FuncDecl, pkgTypes packageTypes) *problem {
+	var args, position int
+	var options *ast.Field
+	for _, param := range funcDecl.Type.Params.List {
+		position += max(len(param.Names), 1)
+		if pkgTypes.checked.isContext(param) {
+			continue
+		}
+		args += max(len(param.Names), 1)
+		if isOptionsParam(param, pkgTypes) {
+			options = param
+			continue
+		}
+		if !isSupportedType(param, pkgTypes) {
+			return newProblem(param, "parameter %d has unsupported type %s", position, pkgTypes.checked.typeString(param.Type))
+		}
 	}
+	if options != nil && args > 1 {
+		return newProblem(options, "options struct %s has to be the only parameter besides the context", pkgTypes.checked.typeString(options.Type))
+	}
+	return nil
 }
 
-func hasErrorReturn(funcDecl *ast.FuncDecl) bool {
-	if funcDecl.Type.Results == nil {
-		return false
-	}
-	if len(funcDecl.Type.Results.List) == 0 {
-		return false
+// typedArgument returns the argument with what's known about its type. A type implementing encoding.TextUnmarshaler
+// is parsed with it, and an enum type is parsed as its underlying type, and only allowed to have the enum's values.
+func typedArgument(name string, typ string, pkgTypes packageTypes) argument {
+	text, isText := pkgTypes.text[typ]
+	arg := argument{
+		Name:       name,
+		Type:       typ,
+		Text:       isText,
+		TypeImport: text.Import,
+	}
+	if enum, isEnum := pkgTypes.enums[typ]; isEnum {
+		if !isText {
+			arg.Underlying = enum.Underlying
+		}
+		arg.AllowedValues = enum.allowed()
+		arg.Enum = enum.Values
 	}
-	// detect if the return type is an error
-	returnType, ok := funcDecl.Type.Results.List[0].Type.(*ast.Ident)
-	if !ok || returnType.Name != "error" {
+	return arg
+}
+
+// hasErrorReturn determines if the function returns an error, which is always the last value returned
+func hasErrorReturn(funcDecl *ast.FuncDecl, checked *checkedPackage) bool {
+	results := resultTypes(funcDecl)
+	if len(results) == 0 {
 		return false
 	}
-	return true
+	return checked.isError(results[len(results)-1])
 }
 
 // Extract information about each exported function. This includes the function name,
@@ -265,96 +471,102 @@ func parsePlainFunc(stmt *ast.FuncDecl) (*function, error) {
 	pCtx := &function{
 		Name: stmt.Name.Name,
 	}
-	if stmt.Doc != nil {
-		pCtx.Comment = strings.TrimSpace(stmt.Doc.Text())
-	}
+	// the description and example can be overridden with the context
+	doc := parseDoc(stmt.Doc)
+	pCtx.Comment = doc.long
+	pCtx.Description = doc.short
+	pCtx.Example = doc.example
+	pCtx.Hidden = hasDirective(stmt.Doc, HIDDEN_DIRECTIVE)
 	return pCtx, nil
 }
 
-// gatherDetails gets the argument and ctx.<method> information
-func gatherDetails(pCtx *function, gogoAlias string, funcDecl *ast.FuncDecl) *function {
+// hasDirective checks if the comment has the directive on a line of its own. The text of a comment leaves
+// its directives out, so they're looked for in its lines.
+func hasDirective(group *ast.CommentGroup, directive string) bool {
+	if group == nil {
+		return false
+	}
+	for _, comment := range group.List {
+		if strings.TrimSpace(comment.Text) == directive {
+			return true
+		}
+	}
+	return false
+}
+
+// gatherDetails gets the argument and ctx.<method> information. An error is returned for an invalid use of the
+// context, which is otherwise ignored, and a *conflictError when a property is set to conflicting values.
+func gatherDetails(fset *token.FileSet, pCtx *function, funcDecl *ast.FuncDecl, pkgTypes packageTypes) (*function, error) {
+	checked := pkgTypes.checked
 	// determine if this has an error return
-	if hasErrorReturn(funcDecl) {
+	if hasErrorReturn(funcDecl, checked) {
 		pCtx.ErrorReturn = true
 	}
+	pCtx.ReturnType = returnType(funcDecl, checked)
+	if results := resultTypes(funcDecl); pCtx.ReturnType != "" {
+		pCtx.Stream = streamKind(checked.resultType(results[0]))
+	}
 
 	// if there no first argument, don't bother trying to parse for a GoGoContext
 	// or any other arguments
 	if len(funcDecl.Type.Params.List) == 0 {
-		return pCtx
+		return pCtx, nil
 	}
 	var args []argument
 	if funcDecl.Type.Params == nil {
-		return pCtx
+		return pCtx, nil
 	}
 
-	// determine if the first argument is a gogo|<alias>.Context
-	hasGoGoCtx := isFirstArgAliasContext(gogoAlias, funcDecl)
+	// determine if the first argument is a gogo.Context, or a context.Context.
+	// The gogo context satisfies context.Context, so it's passed to both.
+	hasStdCtx := checked.isStdContext(funcDecl.Type.Params.List[0])
+	hasGoGoCtx := checked.isGoGoContext(funcDecl.Type.Params.List[0]) || hasStdCtx
 	if hasGoGoCtx {
-		// set the GoGoCtxVariableName
-		pCtx.GoGoCtxVariableName = funcDecl.Type.Params.List[0].Names[0].Name
+		// set the GoGoCtxVariableName. An unnamed context, like func Build(context.Context), is named "_",
+		// since it can't be used in the function anyway
+		pCtx.GoGoCtxVariableName = "_"
+		if names := funcDecl.Type.Params.List[0].Names; len(names) > 0 {
+			pCtx.GoGoCtxVariableName = names[0].Name
+		}
 	}
 
 	// now we can parse the rest of the arguments
-	for _, param := range funcDecl.Type.Params.List {
+	for p, param := range funcDecl.Type.Params.List {
 		for i, name := range param.Names {
-			if hasGoGoCtx && i == 0 && name.Name == pCtx.GoGoCtxVariableName {
+			if hasGoGoCtx && p == 0 && i == 0 {
 				continue
 			}
 
-			typ := GetPlainType(param)
-			args = append(args, argument{
-				Name: name.Name,
-				Type: typ,
-			})
+			typ := checked.typeString(param.Type)
+			arg := typedArgument(name.Name, typ, pkgTypes)
+			arg.Fields = pkgTypes.options[typ]
+			arg.Variadic = strings.HasPrefix(typ, VARIADIC_PREFIX)
+			args = append(args, arg)
 		}
 	}
+	// the help from the doc comment can be overridden with the context
+	applyArgumentHelp(args, parseDoc(funcDecl.Doc).args)
 	pCtx.Arguments = args
 
 	if !hasGoGoCtx {
-		return pCtx
+		return pCtx, nil
 	}
 
 	// we know we have a GoGo context, so make signal it's imported at the very least
 	pCtx.UseGoGoCtx = true
 
-	// extract information using parseGoGoCtx
-	pCtx, err := parseGoGoCtx(pCtx, funcDecl)
-	if err != nil {
-		// we ran into an error
-		fmt.Printf("Error parsing GoGoContext: %v\n", err)
-		return nil
-	}
-
-	return pCtx
-}
-
-// filesHaveFunc determines if any of the given files contain the given function
-func filesHaveFunc(filePaths []string, funcName string) bool {
-	for _, filePath := range filePaths {
-		if fileHasFunc(filePath, funcName) {
-			return true
-		}
+	// a context.Context has none of the gogo methods to describe the function with
+	if hasStdCtx {
+		return pCtx, nil
 	}
-	return false
-}
 
-func fileHasFunc(filePath string, funcName string) bool {
-	fset := token.NewFileSet()
-	file, err := parser.ParseFile(fset, filePath, nil, 0)
+	// extract information using parseGoGoCtx
+	pCtx, err := parseGoGoCtx(fset, pCtx, funcDecl, pkgTypes.values)
 	if err != nil {
-		return false
-	}
-	for _, decl := range file.Decls {
-		funcDecl, ok := decl.(*ast.FuncDecl)
-		if !ok {
-			continue
-		}
-		if funcDecl.Name.Name == funcName {
-			return true
-		}
+		return nil, err
 	}
-	return false
+
+	return pCtx, nil
 }
 
 // getGoGoImportName finds the alias or default name of the GoGo import
@@ -370,31 +582,6 @@ func getGoGoImportName(file *ast.File) (string, bool) {
 	return "", false
 }
 
-// isFirstArgAliasContext checks if the first argument to the FuncDecl is <alias>.Context (e.g., ctx context.Context)
-func isFirstArgAliasContext(alias string, funcDecl *ast.FuncDecl) bool {
-	// Check if the function has any parameters
-	if funcDecl.Type.Params == nil || len(funcDecl.Type.Params.List) == 0 {
-		return false
-	}
-
-	// Get the first parameter
-	firstParam := funcDecl.Type.Params.List[0]
-
-	return isGoGoCtx(alias, firstParam)
-}
-
-func isGoGoCtx(alias string, firstParam *ast.Field) bool {
-	// The type of the first parameter should be a SelectorExpr with the form: alias.Context.
-	if selExpr, ok := firstParam.Type.(*ast.SelectorExpr); ok {
-		// The X field is the alias (it should be an Ident)
-		if ident, ok := selExpr.X.(*ast.Ident); ok {
-			// Check if the identifier name matches the alias and the selector is "Context"
-			return ident.Name == alias && selExpr.Sel.Name == "Context"
-		}
-	}
-	return false
-}
-
 // GetPlainType takes an *ast.Field and returns a plain-English type description
 func GetPlainType(field *ast.Field) string {
 	if field == nil || field.Type == nil {
@@ -419,6 +606,9 @@ func exprToTypeStr(expr ast.Expr) string {
 	case *ast.ArrayType: // Array types, like "[]int", "[]*MyStruct"
 		return "[]" + exprToTypeStr(t.Elt)
 
+	case *ast.Ellipsis: // Variadic parameters, like "...string"
+		return VARIADIC_PREFIX + exprToTypeStr(t.Elt)
+
 	case *ast.MapType: // Map types, like "map[string]int"
 		keyType := exprToTypeStr(t.Key)
 		valueType := exprToTypeStr(t.Value)
@@ -440,6 +630,16 @@ func exprToTypeStr(expr ast.Expr) string {
 		}
 		return "func(" + params + ")"
 
+	case *ast.IndexExpr: // Generic types, like "iter.Seq[string]"
+		return exprToTypeStr(t.X) + "[" + exprToTypeStr(t.Index) + "]"
+
+	case *ast.IndexListExpr: // Generic types with several type arguments, like "iter.Seq2[string, error]"
+		var indices []string
+		for _, index := range t.Indices {
+			indices = append(indices, exprToTypeStr(index))
+		}
+		return exprToTypeStr(t.X) + "[" + strings.Join(indices, ", ") + "]"
+
 	case *ast.ChanType: // Chan types, like chan int, <-chan string, chan<- int
 		dir := "chan "
 		if t.Dir == ast.RECV {
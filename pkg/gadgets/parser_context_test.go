@@ -8,7 +8,9 @@
 package gadgets
 
 import (
+	"bytes"
 	"fmt"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -19,6 +21,7 @@ func TestParse(t *testing.T) {
 	tests := []struct {
 		name     string
 		src      string
+		files    []string // the other files of the package
 		expected function
 	}{
 		{
@@ -35,8 +38,9 @@ func TestParse(t *testing.T) {
 				// This is a comment
 				func NewFunc() {}`,
 			expected: function{
-				Name:    "NewFunc",
-				Comment: "This is a comment",
+				Name:        "NewFunc",
+				Comment:     "This is a comment",
+				Description: "This is a comment",
 			},
 		},
 		{
@@ -77,8 +81,9 @@ func TestParse(t *testing.T) {
 				// This is a comment
 				func NewFunc(arg1 string, arg2 int) {}`,
 			expected: function{
-				Name:    "NewFunc",
-				Comment: "This is a comment",
+				Name:        "NewFunc",
+				Comment:     "This is a comment",
+				Description: "This is a comment",
 				Arguments: []argument{
 					{
 						Name: "arg1",
@@ -129,6 +134,52 @@ Example("This is an example")
 				Arguments:           []argument(nil),
 			},
 		},
+		{
+			name: "hidden with the context",
+			src: fmt.Sprintf(`package gogo
+				import "%s"
+				func Helper(ctx gogo.Context) {
+					ctx.Hidden()
+				}`, GOGOIMPORTPATH),
+			expected: function{
+				Name:                "Helper",
+				Hidden:              true,
+				UseGoGoCtx:          true,
+				GoGoCtxVariableName: "ctx",
+				Arguments:           []argument(nil),
+			},
+		},
+		{
+			name: "required argument",
+			src: fmt.Sprintf(`package gogo
+				import "%s"
+				func Deploy(ctx gogo.Context, env string, wait int) {
+					ctx.Argument(env).Required().Help("where to deploy")
+				}`, GOGOIMPORTPATH),
+			expected: function{
+				Name:                "Deploy",
+				UseGoGoCtx:          true,
+				GoGoCtxVariableName: "ctx",
+				Arguments: []argument{
+					{Name: "env", Type: "string", Help: "where to deploy", Required: true},
+					{Name: "wait", Type: "int"},
+				},
+			},
+		},
+		{
+			name: "hidden with the directive",
+			src: `package gogo
+				// Helper is only run by the other functions
+				//
+				//gogo:hidden
+				func Helper() {}`,
+			expected: function{
+				Name:        "Helper",
+				Comment:     "Helper is only run by the other functions",
+				Description: "Helper is only run by the other functions",
+				Hidden:      true,
+			},
+		},
 		{
 			name: "with gogo context and argument",
 			src: fmt.Sprintf(`package gogo
@@ -221,16 +272,285 @@ ctx.ShortDescription("This is a description")
 				Arguments:           []argument(nil),
 			},
 		},
+		{
+			name: "gogo context command aliases",
+			src: fmt.Sprintf(`package gogo
+				import "%s"
+				func Build(ctx gogo.Context) {
+					ctx.ShortDescription("This is a description").
+					Alias("b", "bld")
+				}`, GOGOIMPORTPATH),
+			expected: function{
+				Name:                "Build",
+				UseGoGoCtx:          true,
+				Description:         "This is a description",
+				GoGoCtxVariableName: "ctx",
+				Aliases:             []string{"b", "bld"},
+				Arguments:           []argument(nil),
+			},
+		},
+		{
+			name: "gogo context calls in separate statements",
+			src: fmt.Sprintf(`package gogo
+				import "%s"
+				func Deploy(ctx gogo.Context, env string, replicas int) {
+					ctx.ShortDescription("Deploy the services")
+					ctx.Argument(env).Help("the environment to deploy to")
+					ctx.Argument(replicas).Short('r')
+					ctx.Argument(env).Default("staging")
+				}`, GOGOIMPORTPATH),
+			expected: function{
+				Name:                "Deploy",
+				UseGoGoCtx:          true,
+				Description:         "Deploy the services",
+				GoGoCtxVariableName: "ctx",
+				Arguments: []argument{
+					{
+						Name:    "env",
+						Type:    "string",
+						Help:    "the environment to deploy to",
+						Default: "staging",
+					},
+					{
+						Name:  "replicas",
+						Type:  "int",
+						Short: 'r',
+					},
+				},
+			},
+		},
+		{
+			name: "gogo context calls in nested statements",
+			src: fmt.Sprintf(`package gogo
+				import "%s"
+				func Watch(ctx gogo.Context, env string) {
+					{
+						ctx.ShortDescription("Watch the services")
+					}
+					switch env {
+					case "prod":
+						ctx.Argument(env).Help("the environment to watch")
+					}
+					for range 3 {
+						ctx.Example("gogo watch prod")
+					}
+					select {
+					case <-ctx.Done():
+						ctx.Alias("w")
+					default:
+					}
+					func() {
+						ctx.Argument(env).Short('e')
+					}()
+				}`, GOGOIMPORTPATH),
+			expected: function{
+				Name:                "Watch",
+				UseGoGoCtx:          true,
+				Description:         "Watch the services",
+				Example:             "gogo watch prod",
+				Aliases:             []string{"w"},
+				GoGoCtxVariableName: "ctx",
+				Arguments: []argument{
+					{
+						Name:  "env",
+						Type:  "string",
+						Help:  "the environment to watch",
+						Short: 'e',
+					},
+				},
+			},
+		},
+		{
+			name: "gogo context property set twice to the same value",
+			src: fmt.Sprintf(`package gogo
+				import "%s"
+				func Build(ctx gogo.Context) {
+					ctx.ShortDescription("Build it").Alias("b")
+					ctx.ShortDescription("Build it").Alias("b", "bld")
+				}`, GOGOIMPORTPATH),
+			expected: function{
+				Name:                "Build",
+				UseGoGoCtx:          true,
+				Description:         "Build it",
+				Aliases:             []string{"b", "bld"},
+				GoGoCtxVariableName: "ctx",
+				Arguments:           []argument(nil),
+			},
+		},
+		{
+			name: "dot imported gogo context",
+			src: fmt.Sprintf(`package gogo
+				import . "%s"
+				func NewFunc(ctx Context) {
+					ctx.ShortDescription("This is a description")
+				}`, GOGOIMPORTPATH),
+			expected: function{
+				Name:                "NewFunc",
+				Description:         "This is a description",
+				UseGoGoCtx:          true,
+				GoGoCtxVariableName: "ctx",
+			},
+		},
+		{
+			name: "gogo context type alias",
+			src: fmt.Sprintf(`package gogo
+				import g "%s"
+				type Ctx = g.Context
+				func NewFunc(ctx Ctx, env string) {
+					ctx.ShortDescription("This is a description")
+				}`, GOGOIMPORTPATH),
+			expected: function{
+				Name:                "NewFunc",
+				Description:         "This is a description",
+				UseGoGoCtx:          true,
+				GoGoCtxVariableName: "ctx",
+				Arguments:           []argument{{Name: "env", Type: "string"}},
+			},
+		},
+		{
+			name: "interface embedding the gogo context",
+			src: fmt.Sprintf(`package gogo
+				import "%s"
+				type Ctx interface{ gogo.Context }
+				func NewFunc(ctx Ctx) {
+					ctx.ShortDescription("This is a description")
+				}`, GOGOIMPORTPATH),
+			expected: function{
+				Name:                "NewFunc",
+				Description:         "This is a description",
+				UseGoGoCtx:          true,
+				GoGoCtxVariableName: "ctx",
+			},
+		},
+		{
+			name: "interface embedding the standard context",
+			src: `package gogo
+				import "context"
+				type Ctx interface{ context.Context }
+				func NewFunc(ctx Ctx) {}`,
+			expected: function{
+				Name:                "NewFunc",
+				UseGoGoCtx:          true,
+				GoGoCtxVariableName: "ctx",
+			},
+		},
+		{
+			name: "interface embedding the gogo context with other methods",
+			src: fmt.Sprintf(`package gogo
+				import "%s"
+				type Ctx interface {
+					gogo.Context
+					Verbose() bool
+				}
+				func NewFunc(ctx Ctx) {}
+				func OtherFunc() {}`, GOGOIMPORTPATH),
+			expected: function{
+				Name: "OtherFunc",
+			},
+		},
+		{
+			name: "types from other files",
+			src: `package gogo
+				func NewFunc(ctx Ctx, env Env, wait Wait) {
+					ctx.ShortDescription("This is a description")
+				}`,
+			files: []string{
+				fmt.Sprintf(`package gogo
+					import "%s"
+					type Ctx = gogo.Context`, GOGOIMPORTPATH),
+				`package gogo
+					import . "time"
+					type Env = string
+					type Wait = Duration`,
+			},
+			expected: function{
+				Name:                "NewFunc",
+				Description:         "This is a description",
+				UseGoGoCtx:          true,
+				GoGoCtxVariableName: "ctx",
+				Arguments: []argument{
+					{Name: "env", Type: "string"},
+					{Name: "wait", Type: "time.Duration"},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			funcs, err := parseSource(tt.src)
+			funcs, err := parseSources(append([]string{tt.src}, tt.files...))
 			require.NoError(t, err)
 			require.Equal(t, tt.expected, funcs[0])
 		})
 	}
 }
 
+func TestParseAliasErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "alias used twice", body: `
+			func Build(ctx gogo.Context) { ctx.Alias("b") }
+			func Bundle(ctx gogo.Context) { ctx.Alias("b") }`},
+		{name: "alias is another function", body: `
+			func Build(ctx gogo.Context) { ctx.Alias("Test") }
+			func Test() {}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSource(fmt.Sprintf("package gogo\nimport \"%s\"\n%s", GOGOIMPORTPATH, tt.body))
+			require.Error(t, err)
+		})
+	}
+
+	// like other invalid uses of the context, the error is reported and the alias is ignored
+	var reported bytes.Buffer
+	warnings = &reported
+	defer func() { warnings = os.Stderr }()
+	funcs, err := parseSource(fmt.Sprintf(`package gogo
+		import "%s"
+		func Build(ctx gogo.Context) { ctx.Alias("docker:b") }`, GOGOIMPORTPATH))
+	require.NoError(t, err)
+	require.Len(t, funcs, 1)
+	require.Empty(t, funcs[0].Aliases)
+	require.Contains(t, reported.String(), "warning: ")
+	require.Contains(t, reported.String(), "Build: invalid alias docker:b")
+}
+
+func TestParseContextConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name: "short description",
+			body: `func Build(ctx gogo.Context) {
+	ctx.ShortDescription("Build it")
+	if true {
+		ctx.ShortDescription("Build everything")
+	}
+}`,
+			expected: `build.go:6:7: ShortDescription of Build is set to "Build everything", but it is already set to "Build it" at build.go:4:6`,
+		},
+		{
+			name: "argument default",
+			body: `func Build(ctx gogo.Context, target string) {
+	ctx.Argument(target).Default("linux")
+	ctx.Argument(target).Help("the target to build").Default("darwin")
+}`,
+			expected: `build.go:5:51: Argument(target).Default of Build is set to "darwin", but it is already set to "linux" at build.go:4:23`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := fmt.Sprintf("package gogo\nimport \"%s\"\n%s", GOGOIMPORTPATH, tt.body)
+			_, err := parseNamedSources([]string{"build.go"}, []string{src})
+			require.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
 // this test assumes many functions exist in the file
 func TestParseMany(t *testing.T) {
 	tests := []struct {
@@ -132,21 +132,81 @@ func TestParsePlainFunc(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:         "variadic argument",
+			src:          "package main\nfunc TestFuncVariadic(cmd string, args ...string) {}",
+			expectedName: "TestFuncVariadic",
+			expectedArgs: []argument{
+				{
+					Name: "cmd",
+					Type: "string",
+				},
+				{
+					Name:     "args",
+					Type:     "...string",
+					Variadic: true,
+				},
+			},
+		},
 		{
 			name:          "function with error return",
 			src:           "package main\nfunc TestErrorFunc() error {}",
 			expectedName:  "TestErrorFunc",
 			expectedError: true,
 		},
+		{
+			name:         "aliased argument types",
+			src:          "package main\nfunc TestFuncAliases(name Name, names ...Name) {}\ntype Name = string",
+			expectedName: "TestFuncAliases",
+			expectedArgs: []argument{
+				{
+					Name: "name",
+					Type: "string",
+				},
+				{
+					Name:     "names",
+					Type:     "...string",
+					Variadic: true,
+				},
+			},
+		},
+		{
+			name:         "renamed and dot imports",
+			src:          "package main\nimport (\n\tt \"time\"\n\t. \"net/url\"\n)\nfunc TestFuncImports(wait t.Duration, endpoint URL) {}",
+			expectedName: "TestFuncImports",
+			expectedArgs: []argument{
+				{
+					Name: "wait",
+					Type: "time.Duration",
+				},
+				{
+					Name: "endpoint",
+					Type: "url.URL",
+				},
+			},
+		},
+		{
+			name:          "function with an aliased error return",
+			src:           "package main\nfunc TestErrorFunc() Failure {}\ntype Failure = error",
+			expectedName:  "TestErrorFunc",
+			expectedError: true,
+		},
+		{
+			name:          "function returning an interface embedding error",
+			src:           "package main\nfunc TestErrorFunc() Failure {}\ntype Failure interface{ error }",
+			expectedName:  "TestErrorFunc",
+			expectedError: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			fset := token.NewFileSet()
-			file, err := parser.ParseFile(fset, "", tt.src, parser.ParseComments)
-			assert.NoError(t, err)
-
-			funcDecl := file.Decls[0].(*ast.FuncDecl)
+			checked := checkSources([]string{""}, []string{tt.src})
+			i := slices.IndexFunc(checked.files[0].Decls, func(decl ast.Decl) bool {
+				_, ok := decl.(*ast.FuncDecl)
+				return ok
+			})
+			funcDecl := checked.files[0].Decls[i].(*ast.FuncDecl)
 			pCtx, err := parsePlainFunc(funcDecl)
 			assert.NoError(t, err)
 			if tt.expectedName != "" {
@@ -156,7 +216,8 @@ func TestParsePlainFunc(t *testing.T) {
 				assert.Equal(t, tt.expectedComment, pCtx.Comment)
 			}
 			// parse args
-			pCtx = gatherDetails(pCtx, "gogo", funcDecl)
+			pCtx, err = gatherDetails(checked.fset, pCtx, funcDecl, packageTypes{checked: checked})
+			require.NoError(t, err)
 			if tt.expectedArgs != nil {
 				assert.Equal(t, tt.expectedArgs, pCtx.Arguments)
 			}
@@ -164,3 +225,93 @@ func TestParsePlainFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestParseUnnamedContext(t *testing.T) {
+	tests := []struct {
+		name         string
+		params       string
+		expectedArgs []argument
+	}{
+		{name: "unnamed context.Context", params: "context.Context"},
+		{name: "unnamed gogo.Context", params: "gogo.Context"},
+		{name: "blank context", params: "_ gogo.Context, name string", expectedArgs: []argument{{Name: "name", Type: "string"}}},
+		{name: "blank context and argument", params: "_ context.Context, _ string", expectedArgs: []argument{{Name: "_", Type: "string"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			funcs, err := parseSource(`package main
+import (
+	"context"
+	"github.com/2bit-software/gogo/pkg/gogo"
+)
+var _ context.Context
+var _ gogo.Context
+func Build(` + tt.params + `) error { return nil }`)
+			require.NoError(t, err)
+			require.Len(t, funcs, 1)
+			assert.True(t, funcs[0].UseGoGoCtx)
+			assert.Equal(t, "_", funcs[0].GoGoCtxVariableName)
+			assert.Equal(t, tt.expectedArgs, funcs[0].Arguments)
+		})
+	}
+}
+
+// returnTypesSource declares the types the functions of TestParseReturnTypes return
+const returnTypesSource = `package main
+import "iter"
+type Service struct{ Name string }
+type Services = []Service
+type Failure = error
+type Fault interface{ error }
+type Lines = iter.Seq[string]
+type Handler func()
+`
+
+func TestParseReturnTypes(t *testing.T) {
+	tests := []struct {
+		results     string
+		supported   bool
+		returnType  string
+		errorReturn bool
+		stream      string
+	}{
+		{results: "", supported: true},
+		{results: "error", supported: true, errorReturn: true},
+		{results: "string", supported: true, returnType: "string"},
+		{results: "([]Service, error)", supported: true, returnType: "[]Service", errorReturn: true},
+		{results: "(services map[string]int, err error)", supported: true, returnType: "map[string]int", errorReturn: true},
+		{results: "*Service", supported: true, returnType: "*Service"},
+		{results: "(error, string)", supported: false},
+		{results: "(string, int)", supported: false},
+		{results: "(a, b string)", supported: false},
+		{results: "(string, int, error)", supported: false},
+		{results: "func()", supported: false},
+		{results: "chan string", supported: false},
+		{results: "chan<- string", supported: false},
+		{results: "<-chan Service", supported: true, returnType: "<-chan Service", stream: STREAM_CHAN},
+		{results: "(<-chan Service, error)", supported: true, returnType: "<-chan Service", errorReturn: true, stream: STREAM_CHAN},
+		{results: "iter.Seq[Service]", supported: true, returnType: "iter.Seq[Service]", stream: STREAM_SEQ},
+		{results: "iter.Seq2[string, error]", supported: true, returnType: "iter.Seq2[string, error]", stream: STREAM_SEQ2},
+		{results: "iter.Seq2[string, int]", supported: false},
+		{results: "iter.Seq[func()]", supported: false},
+		{results: "<-chan chan string", supported: false},
+		{results: "Failure", supported: true, errorReturn: true},
+		{results: "(Services, Fault)", supported: true, returnType: "[]Service", errorReturn: true},
+		{results: "Lines", supported: true, returnType: "iter.Seq[string]", stream: STREAM_SEQ},
+		{results: "iter.Seq2[Service, Failure]", supported: true, returnType: "iter.Seq2[Service, error]", stream: STREAM_SEQ2},
+		{results: "Handler", supported: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.results, func(t *testing.T) {
+			funcs, err := parseSource(returnTypesSource + "func Task() " + tt.results + " {}")
+			require.NoError(t, err)
+			require.Equal(t, tt.supported, len(funcs) == 1)
+			if !tt.supported {
+				return
+			}
+			assert.Equal(t, tt.returnType, funcs[0].ReturnType)
+			assert.Equal(t, tt.errorReturn, funcs[0].ErrorReturn)
+			assert.Equal(t, tt.stream, funcs[0].Stream)
+		})
+	}
+}
@@ -0,0 +1,275 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"fmt"
+	"go/ast"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	SLICE_PREFIX    = "[]"
+	VARIADIC_PREFIX = "..."
+)
+
+// argType describes how the generated binary handles an argument of a given type
+type argType struct {
+	FlagType   string // the urfave flag type the argument is shown as in the help, e.g. `Int` for `gogo.IntFlag`
+	OptionType string // the type of the field in the generated Options struct, when it differs from the argument type
+	Field      string // the expression appended to the Options field to get the argument, e.g. `.Time`
+	Import     string // the package the generated code needs to declare the type
+}
+
+// scalarTypes are the types a function argument can have, and a slice of any of them, unless noted otherwise
+var scalarTypes = map[string]argType{
+	"string":        {FlagType: "String"},
+	"bool":          {FlagType: "Bool"},
+	"int":           {FlagType: "Int"},
+	"int8":          {FlagType: "Int"},
+	"int16":         {FlagType: "Int"},
+	"int32":         {FlagType: "Int"},
+	"int64":         {FlagType: "Int64"},
+	"uint":          {FlagType: "Uint"},
+	"uint8":         {FlagType: "Uint"},
+	"uint16":        {FlagType: "Uint"},
+	"uint32":        {FlagType: "Uint"},
+	"uint64":        {FlagType: "Uint64"},
+	"float32":       {FlagType: "Float64"},
+	"float64":       {FlagType: "Float64"},
+	"time.Duration": {FlagType: "Duration", Import: "time"},
+	// a time.Time can't be parsed by go-flags, so it's parsed into a gogo.Time, which can't be used in a slice.
+	// The urfave TimestampFlag needs a single layout, so it's shown as a string.
+	"time.Time": {FlagType: "String", OptionType: "gogo.Time", Field: ".Time"},
+	// a url.URL can't be parsed from text on its own, so like a time.Time it's parsed into a gogo.URL
+	"url.URL": {FlagType: "String", OptionType: "gogo.URL", Field: ".URL"},
+}
+
+// sliceFlagTypes are the urfave flag types that have a slice flag. Other slices are shown as a StringSlice.
+var sliceFlagTypes = []string{"String", "Int", "Int64", "Uint", "Uint64", "Float64"}
+
+// lookupArgType returns how the generated binary handles the argument type, and if the type is supported at all
+func lookupArgType(typ string) (argType, bool) {
+	elem, isSlice := strings.CutPrefix(typ, SLICE_PREFIX)
+	t, found := scalarTypes[elem]
+	if !found {
+		return argType{}, false
+	}
+	if !isSlice {
+		if t.OptionType == "" {
+			t.OptionType = typ
+		}
+		return t, true
+	}
+	if t.OptionType != "" {
+		return argType{}, false
+	}
+	t.OptionType = typ
+	if slices.Contains(sliceFlagTypes, t.FlagType) {
+		t.FlagType += "Slice"
+	} else {
+		t.FlagType = "StringSlice"
+	}
+	return t, true
+}
+
+// isSupportedType checks if the parameter has one of the scalarTypes, or is a slice of one of them,
+// or has one of the types found to implement encoding.TextUnmarshaler, or one of the enum types. A variadic parameter is supported
+// when a slice of its type is.
+func isSupportedType(param *ast.Field, pkgTypes packageTypes) bool {
+	if param == nil || param.Type == nil {
+		return false
+	}
+	// a fixed size array is printed like a slice, but can't be filled from a flag
+	if array, ok := param.Type.(*ast.ArrayType); ok && array.Len != nil {
+		return false
+	}
+	typ := pkgTypes.checked.typeString(param.Type)
+	if elem, isVariadic := strings.CutPrefix(typ, VARIADIC_PREFIX); isVariadic {
+		_, found := lookupArgType(SLICE_PREFIX + elem)
+		return found
+	}
+	if _, isText := pkgTypes.text[typ]; isText {
+		return true
+	}
+	if _, isEnum := pkgTypes.enums[typ]; isEnum {
+		return true
+	}
+	_, found := lookupArgType(typ)
+	return found
+}
+
+// slicesCheckedTypes are the types whose allowed and restricted values are checked with the slices package
+var slicesCheckedTypes = []string{"string", "int", "bool", "float64"}
+
+// flagArgType returns how the generated binary handles the flag's argument type
+func flagArgType(flag GoFlag) (argType, bool) {
+	if flag.TextType {
+		return argType{
+			FlagType:   "String",
+			OptionType: fmt.Sprintf("gogo.Text[%s, *%s]", flag.Type, flag.Type),
+			Field:      ".Value",
+		}, true
+	}
+	// an enum is parsed like the type it's declared as
+	if flag.Underlying != "" {
+		t, found := lookupArgType(flag.Underlying)
+		t.OptionType = flag.Type
+		return t, found
+	}
+	return lookupArgType(flag.Type)
+}
+
+// flagType returns the urfave flag type the flag is shown as in the help
+func flagType(flag GoFlag) string {
+	t, found := flagArgType(flag)
+	if !found {
+		return strings.Title(flag.Type)
+	}
+	return t.FlagType
+}
+
+// optionType returns the type of the flag's field in the generated Options struct
+func optionType(flag GoFlag) string {
+	t, found := flagArgType(flag)
+	if !found {
+		return flag.Type
+	}
+	return t.OptionType
+}
+
+// optionName returns the name of the flag's field in the generated Options struct, which is the field of the
+// function's options struct when it has one, so the flag names don't have to be go identifiers
+func optionName(flag GoFlag) string {
+	if flag.Field != "" {
+		return flag.Field
+	}
+	return strings.Title(flag.Name)
+}
+
+// hasFlag determines if the command has its own flag with the name, like an argument named `output`
+func hasFlag(cmd GoCmd, name string) bool {
+	return slices.ContainsFunc(cmd.GoFlags, func(flag GoFlag) bool { return flag.Name == name })
+}
+
+// flagUsage returns the help of the flag, marked when the flag is required
+func flagUsage(flag GoFlag) string {
+	if !flag.Required {
+		return flag.Help
+	}
+	return strings.TrimSpace(flag.Help + " (required)")
+}
+
+// requiredFlags returns the names of the required flags of the command, quoted for the generated code
+func requiredFlags(cmd GoCmd) []string {
+	var names []string
+	for _, flag := range cmd.GoFlags {
+		if flag.Required {
+			names = append(names, strconv.Quote(flag.Name))
+		}
+	}
+	return names
+}
+
+// envVar returns the environment variable the flag of the command is read from
+func envVar(cmdName string, flag GoFlag) string {
+	if flag.Env != "" {
+		return flag.Env
+	}
+	return strings.ToUpper(cmdName + "_" + strings.ReplaceAll(flag.Name, "-", "_"))
+}
+
+// optionField returns the expression appended to the flag's Options field to get the value passed to the function
+func optionField(flag GoFlag) string {
+	t, _ := flagArgType(flag)
+	return t.Field
+}
+
+// hasLiteralDefault determines if the default of the flag can be shown as the value of its urfave flag,
+// which is only the case for the builtin types, where the default is a go literal.
+func hasLiteralDefault(flag GoFlag) bool {
+	if flag.TextType || flag.Underlying != "" {
+		return false
+	}
+	t, found := scalarTypes[flag.Type]
+	if !found {
+		// a type the parser doesn't know about, so keep the default as is
+		return !strings.HasPrefix(flag.Type, SLICE_PREFIX)
+	}
+	if t.Import != "" || t.Field != "" {
+		return false
+	}
+	// the default of an options struct field comes from its tag, so it isn't known to be a valid literal
+	if def, isTag := flag.Default.(string); isTag && flag.Field != "" {
+		return isLiteral(flag.Type, def)
+	}
+	return true
+}
+
+// isLiteral determines if the value is a valid go literal of the builtin type, other than a string
+func isLiteral(typ string, value string) bool {
+	var err error
+	switch {
+	case typ == "string":
+		return true
+	case typ == "bool":
+		_, err = strconv.ParseBool(value)
+	case strings.HasPrefix(typ, "int"):
+		_, err = strconv.ParseInt(value, 10, 64)
+	case strings.HasPrefix(typ, "uint"):
+		_, err = strconv.ParseUint(value, 10, 64)
+	case strings.HasPrefix(typ, "float"):
+		_, err = strconv.ParseFloat(value, 64)
+	}
+	return err == nil
+}
+
+// needsImport determines if any of the command's arguments, or its sub-commands' arguments, need the package
+func needsImport(cmd GoCmd, pkg string) bool {
+	for _, sub := range cmd.Commands {
+		if needsImport(sub, pkg) {
+			return true
+		}
+	}
+	flags := cmd.GoFlags
+	if cmd.Variadic != nil {
+		flags = append(slices.Clip(flags), *cmd.Variadic)
+	}
+	for _, flag := range flags {
+		if t, _ := flagArgType(flag); t.Import == pkg {
+			return true
+		}
+	}
+	return false
+}
+
+// typeImports returns the imports the generated main file needs for the argument types of the functions,
+// and the fields of their options structs, that are declared in other packages, like netip.Addr
+func typeImports(funcs []function) []GoImport {
+	var imports []GoImport
+	for _, f := range funcs {
+		var args []argument
+		for _, arg := range f.Arguments {
+			args = append(append(args, arg), arg.Fields...)
+		}
+		for _, arg := range args {
+			if arg.TypeImport == "" {
+				continue
+			}
+			if slices.ContainsFunc(imports, func(i GoImport) bool { return i.Path == arg.TypeImport }) {
+				continue
+			}
+			// the type is written with the name the package is imported as, e.g. `netip.Addr`
+			name, _, _ := strings.Cut(arg.Type, ".")
+			imports = append(imports, GoImport{Alias: name, Path: arg.TypeImport})
+		}
+	}
+	return imports
+}
@@ -0,0 +1,360 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package gadgets
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/2bit-software/gogo/pkg/mod"
+	"github.com/2bit-software/gogo/pkg/sh"
+)
+
+func TestLookupArgType(t *testing.T) {
+	tests := []struct {
+		typ   string
+		want  argType
+		found bool
+	}{
+		{typ: "string", want: argType{FlagType: "String", OptionType: "string"}, found: true},
+		{typ: "int32", want: argType{FlagType: "Int", OptionType: "int32"}, found: true},
+		{typ: "uint64", want: argType{FlagType: "Uint64", OptionType: "uint64"}, found: true},
+		{typ: "time.Duration", want: argType{FlagType: "Duration", OptionType: "time.Duration", Import: "time"}, found: true},
+		{typ: "time.Time", want: argType{FlagType: "String", OptionType: "gogo.Time", Field: ".Time"}, found: true},
+		{typ: "[]string", want: argType{FlagType: "StringSlice", OptionType: "[]string"}, found: true},
+		{typ: "[]int8", want: argType{FlagType: "IntSlice", OptionType: "[]int8"}, found: true},
+		{typ: "[]bool", want: argType{FlagType: "StringSlice", OptionType: "[]bool"}, found: true},
+		{typ: "[]time.Duration", want: argType{FlagType: "StringSlice", OptionType: "[]time.Duration", Import: "time"}, found: true},
+		{typ: "[]time.Time", found: false},
+		{typ: "[][]string", found: false},
+		{typ: "map[string]string", found: false},
+		{typ: "complex128", found: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			got, found := lookupArgType(tt.typ)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseArgumentTypes(t *testing.T) {
+	tests := []struct {
+		signature string
+		supported bool
+	}{
+		{signature: "(timeout time.Duration, since time.Time)", supported: true},
+		{signature: "(services []string, ports []uint16)", supported: true},
+		{signature: "(count int64, ratio float32)", supported: true},
+		{signature: "(ports [3]int)", supported: false},
+		{signature: "(labels map[string]string)", supported: false},
+		{signature: "(name *string)", supported: false},
+		{signature: "(pkgs ...string)", supported: true},
+		{signature: "(cmd string, args ...string)", supported: true},
+		{signature: "(timeouts ...time.Duration)", supported: true},
+		{signature: "(dates ...time.Time)", supported: false},
+		{signature: "(labels ...map[string]string)", supported: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.signature, func(t *testing.T) {
+			funcs, err := parseSource(fmt.Sprintf("package main\nimport \"time\"\nvar _ time.Duration\nfunc Task%s {}", tt.signature))
+			require.NoError(t, err)
+			assert.Equal(t, tt.supported, len(funcs) == 1)
+		})
+	}
+}
+
+func TestBuildTypes(t *testing.T) {
+	l := log.New(os.Stdout, "", log.LstdFlags)
+	root, err := mod.FindModuleRoot()
+	require.NoError(t, err)
+	tmpDir := t.TempDir()
+
+	opts := BuildOpts{
+		DisableCache:   true,
+		SourceDir:      path.Join(root, "scenarios", "types", ".gogo"),
+		BinaryFilepath: path.Join(tmpDir, "gadgets"),
+	}
+	err = Build(l, opts)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+		contains string
+		wantErr  bool
+	}{
+		{
+			name:     "positional",
+			args:     []string{"Deploy", "api,worker", "90s", "3"},
+			expected: "deploy api+worker timeout=1m30s replicas=3",
+		},
+		{
+			name:     "repeated and comma-separated flags",
+			args:     []string{"Deploy", "--services", "api,worker", "--services=db", "--timeout", "1m", "--replicas", "2"},
+			expected: "deploy api+worker+db timeout=1m0s replicas=2",
+		},
+		{
+			name:     "time",
+			args:     []string{"Since", "2024-03-01"},
+			expected: "since 2024-03-01",
+		},
+		{
+			name:     "allowed values",
+			args:     []string{"Scale", "--replicas", "2", "--weights", "0.5,0.5"},
+			expected: "scale replicas=2 weights=[0.5 0.5]",
+		},
+		{
+			name:    "not an allowed value",
+			args:    []string{"Scale", "--replicas", "5"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid duration",
+			args:    []string{"Deploy", "api", "forever"},
+			wantErr: true,
+		},
+		{
+			name:     "text unmarshalers",
+			args:     []string{"Ping", "--addr", "10.0.0.1", "--env", "staging", "--endpoint", "https://example.com/health"},
+			expected: "ping 10.0.0.1 env=staging host=example.com",
+		},
+		{
+			name:     "positional text unmarshalers",
+			args:     []string{"Ping", "::1", "prod", "http://localhost:8080"},
+			expected: "ping ::1 env=prod host=localhost:8080",
+		},
+		{
+			name:    "invalid text value",
+			args:    []string{"Ping", "--addr", "10.0.0.1", "--env", "qa"},
+			wantErr: true,
+		},
+		{
+			name:     "options struct",
+			args:     []string{"Release", "-v", "1.2.0", "--dry-run", "--target", "linux,darwin", "--mirror", "10.0.0.2"},
+			expected: "release 1.2.0 env=staging dry-run=true targets=linux+darwin timeout=5m0s mirror=10.0.0.2",
+		},
+		{
+			name:     "options struct overriding defaults",
+			args:     []string{"Release", "--version", "1.3.0", "--env", "prod", "--timeout", "1m"},
+			expected: "release 1.3.0 env=prod dry-run=false targets= timeout=1m0s mirror=invalid IP",
+		},
+		{
+			name:     "variadic",
+			args:     []string{"Sum", "1", "2", "3"},
+			expected: "sum 6",
+		},
+		{
+			name:     "variadic after the other arguments",
+			args:     []string{"Exec", "ls", "true", "/tmp"},
+			expected: "exec ls /tmp dry-run=true",
+		},
+		{
+			name:     "variadic after --",
+			args:     []string{"Exec", "go", "--", "test", "-run", "TestX", "./..."},
+			expected: "exec go test -run TestX ./... dry-run=false",
+		},
+		{
+			name:     "variadic with flags",
+			args:     []string{"Exec", "--cmd", "docker", "--dryRun", "--", "ps", "-a"},
+			expected: "exec docker ps -a dry-run=true",
+		},
+		{
+			name:    "invalid variadic value",
+			args:    []string{"Sum", "1", "two"},
+			wantErr: true,
+		},
+		{
+			name:     "returned value as text",
+			args:     []string{"Services", "dev"},
+			expected: "NAME    REPLICAS\napi     3\nworker  10",
+		},
+		{
+			name:     "returned value as json",
+			args:     []string{"Services", "dev", "--output", "json"},
+			expected: "[\n  {\n    \"name\": \"api\",\n    \"replicas\": 3\n  },\n  {\n    \"name\": \"worker\",\n    \"replicas\": 10\n  }\n]",
+		},
+		{
+			name:     "returned value as yaml with the global flag",
+			args:     []string{"--output", "yaml", "Services", "--env", "dev"},
+			expected: "- name: api\n  replicas: 3\n- name: worker\n  replicas: 10",
+		},
+		{
+			name:     "returned value without an error",
+			args:     []string{"Version", "--output=json"},
+			expected: `"1.2.0"`,
+		},
+		{
+			name:    "returned error",
+			args:    []string{"Services", "prod"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown output format",
+			args:    []string{"Version", "--output", "xml"},
+			wantErr: true,
+		},
+		{
+			name:     "streamed sequence as json lines",
+			args:     []string{"Tail", "2", "--output", "jsonl"},
+			expected: "\"line 1\"\n\"line 2\"",
+		},
+		{
+			name:    "streamed sequence error",
+			args:    []string{"Tail", "5"},
+			wantErr: true,
+		},
+		{
+			name:     "streamed channel as text",
+			args:     []string{"Watch"},
+			expected: "NAME\tREPLICAS\napi\t3\nworker\t10",
+		},
+		{
+			name:     "streamed channel as json",
+			args:     []string{"--output", "json", "Watch"},
+			expected: "[\n  {\n    \"name\": \"api\",\n    \"replicas\": 3\n  },\n  {\n    \"name\": \"worker\",\n    \"replicas\": 10\n  }\n]",
+		},
+		{
+			name:     "allowed values from constants",
+			args:     []string{"Rollout", "staging", "10", "1s"},
+			expected: "rollout env=staging batch=10 wait=1s",
+		},
+		{
+			name:    "value not allowed by a typed constant",
+			args:    []string{"Rollout", "prod", "10", "1s"},
+			wantErr: true,
+		},
+		{
+			name:    "value not allowed by a constant expression",
+			args:    []string{"Rollout", "dev", "3", "1s"},
+			wantErr: true,
+		},
+		{
+			name:     "enum argument",
+			args:     []string{"Log", "debug", "deployed"},
+			expected: "log level=debug message=deployed",
+		},
+		{
+			name:    "value not in the enum",
+			args:    []string{"Log", "loud", "deployed"},
+			wantErr: true,
+		},
+		{
+			name:     "enum values in the help",
+			args:     []string{"Log", "--help"},
+			contains: "one of: quiet (only logs errors), info (logs what's done), debug (logs everything)",
+		},
+		{
+			name:    "options struct with positional arguments",
+			args:    []string{"Release", "1.2.0"},
+			wantErr: true,
+		},
+		{
+			name:     "required options struct field",
+			args:     []string{"Release", "--env", "prod"},
+			contains: "missing required argument: version",
+			wantErr:  true,
+		},
+		{
+			name:     "required arguments given by position",
+			args:     []string{"Copy", "example.com", "0", "a.txt", "b.txt"},
+			expected: "copy a.txt+b.txt to example.com:0",
+		},
+		{
+			name:     "required argument given its zero value",
+			args:     []string{"Copy", "--host", "example.com", "--port", "0"},
+			contains: "missing required argument: files",
+			wantErr:  true,
+		},
+		{
+			name:     "every missing required argument",
+			args:     []string{"Copy", "--port=22"},
+			contains: "missing required arguments: host, files",
+			wantErr:  true,
+		},
+		{
+			name:     "required arguments in the help",
+			args:     []string{"Copy", "--help"},
+			contains: "--host value  (required)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := sh.Cmd(opts.BinaryFilepath).SetArgs(tt.args...).String()
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, out, tt.contains)
+				return
+			}
+			require.NoError(t, err)
+			if tt.contains != "" {
+				assert.Contains(t, out, tt.contains)
+				return
+			}
+			assert.Equal(t, tt.expected, strings.TrimSpace(out))
+		})
+	}
+}
+
+func TestParseTextTypes(t *testing.T) {
+	tests := []struct {
+		name       string
+		src        string
+		supported  bool
+		typeImport string
+	}{
+		{
+			name:      "local type",
+			src:       "type Env string\nfunc (e *Env) UnmarshalText(text []byte) error { return nil }\nfunc Task(env Env) {}",
+			supported: true,
+		},
+		{
+			name:       "standard library type",
+			src:        "import \"net/netip\"\nfunc Task(addr netip.Addr) {}",
+			supported:  true,
+			typeImport: "net/netip",
+		},
+		{
+			name:      "local type without UnmarshalText",
+			src:       "type Env string\nfunc Task(env Env) {}",
+			supported: false,
+		},
+		{
+			name:      "type from outside the standard library",
+			src:       "import \"github.com/google/uuid\"\nfunc Task(id uuid.UUID) {}",
+			supported: false,
+		},
+		{
+			name:      "slice of text type",
+			src:       "import \"net/netip\"\nfunc Task(addrs []netip.Addr) {}",
+			supported: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			funcs, err := parseSource("package main\n" + tt.src)
+			require.NoError(t, err)
+			require.Equal(t, tt.supported, len(funcs) == 1)
+			if !tt.supported {
+				return
+			}
+			require.Len(t, funcs[0].Arguments, 1)
+			assert.True(t, funcs[0].Arguments[0].Text)
+			assert.Equal(t, tt.typeImport, funcs[0].Arguments[0].TypeImport)
+		})
+	}
+}
@@ -1,16 +1,258 @@
 package gogo
 
 import (
+	"encoding"
+	"errors"
 	"fmt"
+	"net/url"
+	"os"
 	"reflect"
+	"regexp"
+	"slices"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jessevdk/go-flags"
 )
 
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// ParseArgs parses the flags in the args into the options. A slice option is filled by repeating its flag,
+// by a comma-separated value, or both, e.g. `--tag a,b --tag c`.
 func ParseArgs(options any, args []string) ([]string, error) {
-	return flags.ParseArgs(options, args)
+	// errors aren't printed by go-flags, since they're returned to the caller, only the help is
+	parser := flags.NewParser(options, flags.HelpFlag|flags.PassDoubleDash)
+	positional, err := parser.ParseArgs(splitSliceValues(options, args))
+	if flags.WroteHelp(err) {
+		fmt.Println(err)
+	}
+	return positional, friendlyParseError(err)
+}
+
+// friendlyParseError rewrites the error go-flags returns for an invalid value, which names the go type of
+// the option, e.g. "invalid argument for flag `--env' (expected gogo.Text[main.Env,*main.Env]): unknown env",
+// into an error that only names the flag: "invalid value for flag '--env': unknown env".
+func friendlyParseError(err error) error {
+	var flagsErr *flags.Error
+	if !errors.As(err, &flagsErr) || flagsErr.Type != flags.ErrMarshal {
+		return err
+	}
+	match := marshalErrorPattern.FindStringSubmatch(flagsErr.Message)
+	if match == nil {
+		return err
+	}
+	return fmt.Errorf("invalid value for flag '%s': %s", match[1], match[2])
+}
+
+var marshalErrorPattern = regexp.MustCompile("^invalid argument for flag `([^']+)'(?: \\(expected .*?\\))?: (.*)$")
+
+// splitSliceValues expands the comma-separated values of slice options into a repeated flag for each value
+func splitSliceValues(options any, args []string) []string {
+	val := reflect.ValueOf(options)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return args
+	}
+	typ := val.Elem().Type()
+	var sliceFlags []string
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Type.Kind() != reflect.Slice {
+			continue
+		}
+		if long := field.Tag.Get("long"); long != "" {
+			sliceFlags = append(sliceFlags, "--"+long)
+		}
+		if short := field.Tag.Get("short"); short != "" {
+			sliceFlags = append(sliceFlags, "-"+short)
+		}
+	}
+	if len(sliceFlags) == 0 {
+		return args
+	}
+
+	var expanded []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		// everything after -- is positional
+		if arg == "--" {
+			return append(expanded, args[i:]...)
+		}
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !slices.Contains(sliceFlags, name) {
+			expanded = append(expanded, arg)
+			continue
+		}
+		if !hasValue {
+			// the value is the next argument
+			if i+1 >= len(args) {
+				expanded = append(expanded, arg)
+				continue
+			}
+			i++
+			value = args[i]
+		}
+		for _, v := range strings.Split(value, ",") {
+			expanded = append(expanded, name, v)
+		}
+	}
+	return expanded
+}
+
+// Time is a time.Time argument, given in RFC3339 format (2006-01-02T15:04:05Z07:00) or as a date (2006-01-02).
+// The generated binary parses time.Time arguments into this type, and passes the time.Time to the function.
+type Time struct {
+	time.Time
+}
+
+// UnmarshalFlag parses the time from the flag or positional argument
+func (t *Time) UnmarshalFlag(value string) error {
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		parsed, err := time.Parse(layout, value)
+		if err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid time %q, expected RFC3339 (%s) or a date (%s)", value, time.RFC3339, time.DateOnly)
+}
+
+// URL is a url.URL argument. url.URL can't be parsed from text on its own, so the generated binary parses
+// url.URL arguments into this type, and passes the url.URL to the function.
+type URL struct {
+	url.URL
+}
+
+// UnmarshalFlag parses the URL from the flag or positional argument
+func (u *URL) UnmarshalFlag(value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	u.URL = *parsed
+	return nil
+}
+
+// Text is an argument of a type that implements encoding.TextUnmarshaler, like netip.Addr, or a type of the
+// gadgets themselves. The generated binary parses the argument into this type, and passes the Value to the function.
+type Text[T any, PT interface {
+	*T
+	encoding.TextUnmarshaler
+}] struct {
+	Value T
+}
+
+// UnmarshalFlag parses the value from the flag or positional argument with its UnmarshalText method
+func (t *Text[T, PT]) UnmarshalFlag(value string) error {
+	return PT(&t.Value).UnmarshalText([]byte(value))
+}
+
+// CheckAllowedValues returns an error if the value of the flag is not one of the allowed values.
+// For a slice, every element must be allowed. The allowed values are parsed like the flag's value.
+func CheckAllowedValues(name string, value any, allowed ...string) error {
+	found, err := containsValues(value, allowed)
+	if err != nil {
+		return fmt.Errorf("invalid allowed values for flag '%s': %w", name, err)
+	}
+	if slices.Contains(found, false) {
+		return fmt.Errorf("flag '%s' must be one of: %s", name, strings.Join(allowed, ", "))
+	}
+	return nil
+}
+
+// CheckRestrictedValues returns an error if the value of the flag is one of the restricted values.
+// For a slice, no element may be restricted. The restricted values are parsed like the flag's value.
+func CheckRestrictedValues(name string, value any, restricted ...string) error {
+	found, err := containsValues(value, restricted)
+	if err != nil {
+		return fmt.Errorf("invalid restricted values for flag '%s': %w", name, err)
+	}
+	if slices.Contains(found, true) {
+		return fmt.Errorf("flag '%s' cannot be set to: %s", name, strings.Join(restricted, ", "))
+	}
+	return nil
+}
+
+// MissingArguments returns the required arguments that weren't given, by the name of their flag. An argument is
+// given with its flag, its environment variable or as a positional argument, so one given its zero value, like
+// `--count 0`, isn't missing. The args are parsed again, into new options, like ParseArgs and HydrateRemaining do.
+func MissingArguments(options any, args []string, required ...string) []string {
+	val := reflect.ValueOf(options)
+	if len(required) == 0 || val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	parsed := reflect.New(val.Elem().Type())
+	parser := flags.NewParser(parsed.Interface(), flags.PassDoubleDash)
+	positional, err := parser.ParseArgs(splitSliceValues(parsed.Interface(), args))
+	if err != nil {
+		// the errors of the args were already returned when they were parsed
+		return nil
+	}
+	given := map[string]bool{}
+	for _, name := range required {
+		option := parser.FindOptionByLongName(name)
+		if option == nil {
+			continue
+		}
+		if env := option.EnvKeyWithNamespace(); env != "" {
+			_, given[name] = os.LookupEnv(env)
+		}
+		given[name] = given[name] || option.IsSet()
+	}
+	_, filled, err := hydrate(parsed.Interface(), positional)
+	if err != nil {
+		return nil
+	}
+	for _, index := range filled {
+		given[parsed.Elem().Type().Field(index).Tag.Get("long")] = true
+	}
+	var missing []string
+	for _, name := range required {
+		if !given[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// MissingError returns an error listing the required arguments that weren't given, or nil when none are missing
+func MissingError(missing []string) error {
+	switch len(missing) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("missing required argument: %s", missing[0])
+	}
+	return fmt.Errorf("missing required arguments: %s", strings.Join(missing, ", "))
+}
+
+// containsValues reports, for the value or each element of a slice value, whether it is one of the candidates
+func containsValues(value any, candidates []string) ([]bool, error) {
+	val := reflect.ValueOf(value)
+	elems := []reflect.Value{val}
+	if val.Kind() == reflect.Slice {
+		elems = nil
+		for i := 0; i < val.Len(); i++ {
+			elems = append(elems, val.Index(i))
+		}
+	}
+	var found []bool
+	for _, elem := range elems {
+		contains := false
+		for _, candidate := range candidates {
+			parsed := reflect.New(elem.Type()).Elem()
+			if err := setFieldFromString(parsed, candidate, candidate); err != nil {
+				return nil, err
+			}
+			if reflect.DeepEqual(parsed.Interface(), elem.Interface()) {
+				contains = true
+				break
+			}
+		}
+		found = append(found, contains)
+	}
+	return found, nil
 }
 
 // HydrateFromPositional fills struct fields with values from positional arguments
@@ -19,11 +261,26 @@ func ParseArgs(options any, args []string) ([]string, error) {
 // This function allows defining command argument handling in a centralized way,
 // supporting both flag-based and positional arguments in a priority order.
 func HydrateFromPositional(opts any, positional []string) error {
+	_, err := HydrateRemaining(opts, positional)
+	return err
+}
+
+// HydrateRemaining fills the struct fields from the positional arguments like HydrateFromPositional,
+// and returns the positional arguments that weren't used for a field, in their original order.
+// These are passed to the variadic parameter of a function.
+func HydrateRemaining(opts any, positional []string) ([]string, error) {
+	remaining, _, err := hydrate(opts, positional)
+	return remaining, err
+}
+
+// hydrate fills the struct fields from the positional arguments, and returns the positional arguments that
+// weren't used, along with the indexes of the fields that were filled
+func hydrate(opts any, positional []string) ([]string, []int, error) {
 	val := reflect.ValueOf(opts)
 
 	// Ensure we're working with a pointer to a struct
 	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
-		return fmt.Errorf("expected pointer to struct, got %T", opts)
+		return nil, nil, fmt.Errorf("expected pointer to struct, got %T", opts)
 	}
 
 	val = val.Elem() // Get the struct value
@@ -55,7 +312,7 @@ func HydrateFromPositional(opts any, positional []string) error {
 
 		position, err := strconv.Atoi(orderTag)
 		if err != nil {
-			return fmt.Errorf("invalid order tag for field %s: %w", field.Name, err)
+			return nil, nil, fmt.Errorf("invalid order tag for field %s: %w", field.Name, err)
 		}
 
 		// Check if field already has a value
@@ -73,11 +330,12 @@ func HydrateFromPositional(opts any, positional []string) error {
 		return fields[i].position < fields[j].position
 	})
 
-	// Track which positional args have been used
+	// Track which positional args have been used, and which fields they filled
 	usedArgs := make([]bool, len(positional))
+	var filled []int
 
 	// First pass: assign positional args to fields in order
-	for _, field := range fields {
+	for i, field := range fields {
 		if field.isSet {
 			continue
 		}
@@ -97,6 +355,8 @@ func HydrateFromPositional(opts any, positional []string) error {
 		value := positional[field.position]
 		// if the field is just two quotes, skip setting it.
 		// this is when a positional argument calling a gadget is filled with two quotes to represent a blank value
+		// the field is given either way, so the second pass doesn't fill it, even when its value is the zero value
+		fields[i].isSet = true
 		if value == `""` || value == `\"\"` {
 			// and skip setting the field
 			usedArgs[field.position] = true
@@ -105,10 +365,11 @@ func HydrateFromPositional(opts any, positional []string) error {
 
 		// Set the value based on field type
 		if err := setFieldFromString(fieldVal, value, fieldType.Name); err != nil {
-			return err
+			return nil, nil, err
 		}
 
 		usedArgs[field.position] = true
+		filled = append(filled, field.index)
 	}
 
 	// Second pass: use any remaining args for unfilled fields
@@ -132,19 +393,84 @@ func HydrateFromPositional(opts any, positional []string) error {
 
 		// Set the value
 		if err := setFieldFromString(fieldVal, positional[argIndex], fieldType.Name); err != nil {
-			return err
+			return nil, nil, err
 		}
 
 		usedArgs[argIndex] = true
+		filled = append(filled, field.index)
 		argIndex++
 	}
 
+	var remaining []string
+	for i, arg := range positional {
+		if !usedArgs[i] {
+			remaining = append(remaining, arg)
+		}
+	}
+	return remaining, filled, nil
+}
+
+// SplitPassthrough splits the args at the first `--`, into the args for the function's flags and positional
+// arguments, and the args after it, which are passed as they are to the variadic parameter of the function.
+func SplitPassthrough(args []string) ([]string, []string) {
+	i := slices.Index(args, "--")
+	if i == -1 {
+		return args, nil
+	}
+	return args[:i], args[i+1:]
+}
+
+// ParseValues parses each of the values into an element of the slice the target points to,
+// like the positional arguments passed to a variadic parameter, e.g. `func Test(pkgs ...string)`.
+// Unlike a slice flag, a value isn't split on commas.
+func ParseValues(target any, values []string) error {
+	val := reflect.ValueOf(target)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("expected pointer to slice, got %T", target)
+	}
+	slice := val.Elem()
+	for i, value := range values {
+		elem := reflect.New(slice.Type().Elem()).Elem()
+		if err := setFieldFromString(elem, value, fmt.Sprintf("argument %d", i+1)); err != nil {
+			return err
+		}
+		slice.Set(reflect.Append(slice, elem))
+	}
 	return nil
 }
 
-// setFieldFromString sets a field value from a string, with appropriate type conversion
+// setFieldFromString sets a field value from a string, with appropriate type conversion.
+// A slice is set from a comma-separated list of its elements.
 func setFieldFromString(field reflect.Value, value string, fieldName string) error {
+	if field.CanAddr() {
+		if unmarshaler, ok := field.Addr().Interface().(flags.Unmarshaler); ok {
+			if err := unmarshaler.UnmarshalFlag(value); err != nil {
+				return fmt.Errorf("invalid value for %s: %w", fieldName, err)
+			}
+			return nil
+		}
+	}
+	if field.Type() == durationType {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration value for %s: %w", fieldName, err)
+		}
+		field.SetInt(int64(duration))
+		return nil
+	}
+
 	switch field.Kind() {
+	case reflect.Slice:
+		parts := strings.Split(value, ",")
+		elems := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setFieldFromString(elems.Index(i), part, fieldName); err != nil {
+				return err
+			}
+		}
+		field.Set(elems)
+		return nil
+
 	case reflect.String:
 		field.SetString(value)
 		return nil
@@ -1,12 +1,28 @@
 package gogo
 
 import (
+	"fmt"
+	"net/netip"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// testEnv is a type that validates itself when parsed from text
+type testEnv string
+
+func (e *testEnv) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "dev", "prod":
+		*e = testEnv(text)
+		return nil
+	}
+	return fmt.Errorf("unknown environment %q", text)
+}
+
 // fieldOf creates a settable reflect.Value of the given type for testing.
 func fieldOf[T any]() reflect.Value {
 	var v T
@@ -163,12 +179,120 @@ func TestSetFieldFromString(t *testing.T) {
 			wantErr:   "overflows",
 		},
 
-		// Unsupported type
+		// Duration
+		{
+			name:      "duration 1m30s",
+			field:     fieldOf[time.Duration](),
+			value:     "1m30s",
+			fieldName: "Timeout",
+			check: func(t *testing.T, f reflect.Value) {
+				assert.Equal(t, 90*time.Second, f.Interface())
+			},
+		},
 		{
-			name:      "unsupported slice type",
+			name:      "duration without unit",
+			field:     fieldOf[time.Duration](),
+			value:     "90",
+			fieldName: "Timeout",
+			wantErr:   "invalid duration value for Timeout",
+		},
+
+		// Time
+		{
+			name:      "time RFC3339",
+			field:     fieldOf[Time](),
+			value:     "2024-03-01T10:00:00Z",
+			fieldName: "Since",
+			check: func(t *testing.T, f reflect.Value) {
+				assert.Equal(t, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), f.Interface().(Time).Time)
+			},
+		},
+		{
+			name:      "time date",
+			field:     fieldOf[Time](),
+			value:     "2024-03-01",
+			fieldName: "Since",
+			check: func(t *testing.T, f reflect.Value) {
+				assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), f.Interface().(Time).Time)
+			},
+		},
+		{
+			name:      "time invalid",
+			field:     fieldOf[Time](),
+			value:     "yesterday",
+			fieldName: "Since",
+			wantErr:   "invalid value for Since",
+		},
+
+		// Slices
+		{
+			name:      "string slice",
 			field:     fieldOf[[]string](),
+			value:     "api,worker",
+			fieldName: "Services",
+			check: func(t *testing.T, f reflect.Value) {
+				assert.Equal(t, []string{"api", "worker"}, f.Interface())
+			},
+		},
+		{
+			name:      "int slice",
+			field:     fieldOf[[]int](),
+			value:     "1,2,3",
+			fieldName: "Ports",
+			check: func(t *testing.T, f reflect.Value) {
+				assert.Equal(t, []int{1, 2, 3}, f.Interface())
+			},
+		},
+		{
+			name:      "int slice invalid element",
+			field:     fieldOf[[]int](),
+			value:     "1,two",
+			fieldName: "Ports",
+			wantErr:   "invalid integer value for Ports",
+		},
+
+		// TextUnmarshaler
+		{
+			name:      "text type",
+			field:     fieldOf[Text[testEnv, *testEnv]](),
+			value:     "prod",
+			fieldName: "Env",
+			check: func(t *testing.T, f reflect.Value) {
+				assert.Equal(t, testEnv("prod"), f.Interface().(Text[testEnv, *testEnv]).Value)
+			},
+		},
+		{
+			name:      "text type invalid",
+			field:     fieldOf[Text[testEnv, *testEnv]](),
+			value:     "qa",
+			fieldName: "Env",
+			wantErr:   `invalid value for Env: unknown environment "qa"`,
+		},
+		{
+			name:      "imported text type",
+			field:     fieldOf[Text[netip.Addr, *netip.Addr]](),
+			value:     "10.0.0.1",
+			fieldName: "Addr",
+			check: func(t *testing.T, f reflect.Value) {
+				assert.Equal(t, netip.MustParseAddr("10.0.0.1"), f.Interface().(Text[netip.Addr, *netip.Addr]).Value)
+			},
+		},
+		{
+			name:      "url",
+			field:     fieldOf[URL](),
+			value:     "https://example.com/path",
+			fieldName: "Endpoint",
+			check: func(t *testing.T, f reflect.Value) {
+				assert.Equal(t, "example.com", f.Interface().(URL).Host)
+			},
+		},
+
+		// Unsupported type
+		{
+			name:      "unsupported map type",
+			field:     fieldOf[map[string]string](),
 			value:     "anything",
-			fieldName: "Tags",
+			fieldName: "Labels",
 			wantErr:   "unsupported field type for",
 		},
 	}
@@ -215,6 +339,20 @@ func TestHydrateFromPositional(t *testing.T) {
 		assert.Equal(t, "y", o.B)
 	})
 
+	t.Run("slice, duration and time fields", func(t *testing.T) {
+		type opts struct {
+			Services []string      `order:"0"`
+			Timeout  time.Duration `order:"1"`
+			Since    Time          `order:"2"`
+		}
+		o := &opts{}
+		err := HydrateFromPositional(o, []string{"api,worker", "10s", "2024-03-01"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"api", "worker"}, o.Services)
+		assert.Equal(t, 10*time.Second, o.Timeout)
+		assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), o.Since.Time)
+	})
+
 	t.Run("empty quotes consumed but field not set", func(t *testing.T) {
 		type opts struct {
 			A string `order:"0"`
@@ -361,6 +499,90 @@ func TestHydrateFromPositional(t *testing.T) {
 	})
 }
 
+func TestHydrateRemaining(t *testing.T) {
+	type opts struct {
+		Cmd     string `order:"0"`
+		Verbose bool   `long:"verbose"`
+	}
+	tests := []struct {
+		name       string
+		opts       opts
+		positional []string
+		want       opts
+		remaining  []string
+	}{
+		{
+			name:       "remaining after the fields",
+			positional: []string{"ls", "-la", "/tmp"},
+			want:       opts{Cmd: "ls"},
+			remaining:  []string{"-la", "/tmp"},
+		},
+		{
+			name:       "field set by a flag",
+			opts:       opts{Cmd: "ls"},
+			positional: []string{"-la", "/tmp"},
+			want:       opts{Cmd: "ls"},
+			remaining:  []string{"-la", "/tmp"},
+		},
+		{
+			name:       "empty quotes are consumed",
+			positional: []string{`""`},
+		},
+		{
+			name:       "nothing remaining",
+			positional: []string{"ls"},
+			want:       opts{Cmd: "ls"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := tt.opts
+			remaining, err := HydrateRemaining(&o, tt.positional)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, o)
+			assert.Equal(t, tt.remaining, remaining)
+		})
+	}
+
+	// a positional zero value fills its field, rather than leaving it for the next argument
+	type counted struct {
+		Count int    `order:"0"`
+		Name  string `order:"1"`
+	}
+	var c counted
+	remaining, err := HydrateRemaining(&c, []string{"0", "api", "extra"})
+	require.NoError(t, err)
+	assert.Equal(t, counted{Name: "api"}, c)
+	assert.Equal(t, []string{"extra"}, remaining)
+}
+
+func TestSplitPassthrough(t *testing.T) {
+	args, passthrough := SplitPassthrough([]string{"--cmd", "ls", "--", "-la", "--", "/tmp"})
+	assert.Equal(t, []string{"--cmd", "ls"}, args)
+	assert.Equal(t, []string{"-la", "--", "/tmp"}, passthrough)
+
+	args, passthrough = SplitPassthrough([]string{"ls"})
+	assert.Equal(t, []string{"ls"}, args)
+	assert.Nil(t, passthrough)
+}
+
+func TestParseValues(t *testing.T) {
+	var pkgs []string
+	require.NoError(t, ParseValues(&pkgs, []string{"./...", "-run=A,B"}))
+	assert.Equal(t, []string{"./...", "-run=A,B"}, pkgs)
+
+	var ports []uint16
+	require.NoError(t, ParseValues(&ports, []string{"80", "443"}))
+	assert.Equal(t, []uint16{80, 443}, ports)
+
+	var timeouts []time.Duration
+	require.NoError(t, ParseValues(&timeouts, nil))
+	assert.Nil(t, timeouts)
+
+	assert.ErrorContains(t, ParseValues(&ports, []string{"http"}), "argument 1")
+	assert.ErrorContains(t, ParseValues(pkgs, []string{"x"}), "expected pointer to slice")
+}
+
 func TestParseArgs(t *testing.T) {
 	t.Run("flag sets field no positional", func(t *testing.T) {
 		type opts struct {
@@ -392,4 +614,123 @@ func TestParseArgs(t *testing.T) {
 		_, err := ParseArgs(o, []string{"--unknown"})
 		assert.Error(t, err)
 	})
+
+	t.Run("repeated and comma-separated flags fill a slice", func(t *testing.T) {
+		type opts struct {
+			Services []string `short:"s" long:"service"`
+			Ports    []int    `long:"port"`
+			Name     string   `long:"name"`
+		}
+		o := &opts{}
+		positional, err := ParseArgs(o, []string{"--service=api,worker", "-s", "db", "--port", "80,443", "--name", "a,b", "--", "--service=x"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"api", "worker", "db"}, o.Services)
+		assert.Equal(t, []int{80, 443}, o.Ports)
+		assert.Equal(t, "a,b", o.Name, "only slices are split")
+		assert.Equal(t, []string{"--service=x"}, positional)
+	})
+
+	t.Run("duration and time flags", func(t *testing.T) {
+		type opts struct {
+			Timeout time.Duration `long:"timeout"`
+			Since   Time          `long:"since"`
+		}
+		o := &opts{}
+		_, err := ParseArgs(o, []string{"--timeout", "5m", "--since", "2024-03-01"})
+		assert.NoError(t, err)
+		assert.Equal(t, 5*time.Minute, o.Timeout)
+		assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), o.Since.Time)
+	})
+}
+
+func TestParseArgsFriendlyErrors(t *testing.T) {
+	type opts struct {
+		Env  Text[testEnv, *testEnv] `long:"env"`
+		Port int                     `long:"port"`
+	}
+	_, err := ParseArgs(&opts{}, []string{"--env", "qa"})
+	assert.EqualError(t, err, `invalid value for flag '--env': unknown environment "qa"`)
+
+	_, err = ParseArgs(&opts{}, []string{"--port", "http"})
+	assert.ErrorContains(t, err, "invalid value for flag '--port': ")
+
+	o := &opts{}
+	_, err = ParseArgs(o, []string{"--env=dev"})
+	assert.NoError(t, err)
+	assert.Equal(t, testEnv("dev"), o.Env.Value)
+}
+
+func TestCheckAllowedValues(t *testing.T) {
+	assert.NoError(t, CheckAllowedValues("timeout", 30*time.Second, "30s", "1m"))
+	assert.EqualError(t, CheckAllowedValues("timeout", 5*time.Second, "30s", "1m"), "flag 'timeout' must be one of: 30s, 1m")
+	assert.NoError(t, CheckAllowedValues("port", uint16(80), "80", "443"))
+	assert.NoError(t, CheckAllowedValues("service", []string{"api", "db"}, "api", "db", "worker"))
+	assert.Error(t, CheckAllowedValues("service", []string{"api", "cache"}, "api", "db", "worker"))
+	assert.ErrorContains(t, CheckAllowedValues("port", 80, "http"), "invalid allowed values for flag 'port'")
+
+	assert.NoError(t, CheckRestrictedValues("service", []string{"api"}, "db"))
+	assert.EqualError(t, CheckRestrictedValues("service", []string{"api", "db"}, "db"), "flag 'service' cannot be set to: db")
+}
+
+func TestMissingArguments(t *testing.T) {
+	type opts struct {
+		Env   string `long:"env" order:"0"`
+		Count int    `short:"c" long:"count" order:"1"`
+		Force bool   `long:"force" order:"2"`
+	}
+	tests := []struct {
+		name    string
+		args    []string
+		missing []string
+	}{
+		{
+			name:    "nothing given",
+			missing: []string{"env", "count", "force"},
+		},
+		{
+			name:    "positional arguments",
+			args:    []string{"prod", "0"},
+			missing: []string{"force"},
+		},
+		{
+			name: "zero values given as flags",
+			args: []string{"--count", "0", "--force=false", "prod"},
+		},
+		{
+			name:    "short flag",
+			args:    []string{"-c", "3"},
+			missing: []string{"env", "force"},
+		},
+		{
+			name:    "empty quotes aren't a value",
+			args:    []string{`""`, "--count=1", "--force"},
+			missing: []string{"env"},
+		},
+		{
+			name:    "invalid args were already reported",
+			args:    []string{"--unknown"},
+			missing: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.missing, MissingArguments(&opts{}, tt.args, "env", "count", "force"))
+		})
+	}
+
+	assert.Nil(t, MissingArguments(&opts{}, nil))
+
+	// the fields of an options struct are also read from their environment variable
+	type deployOpts struct {
+		Env      string `long:"env" env:"DEPLOY_ENV"`
+		Replicas int    `long:"replicas" env:"DEPLOY_REPLICAS"`
+	}
+	t.Setenv("DEPLOY_REPLICAS", "0")
+	assert.Equal(t, []string{"env"}, MissingArguments(&deployOpts{}, nil, "env", "replicas"))
+}
+
+func TestMissingError(t *testing.T) {
+	assert.NoError(t, MissingError(nil))
+	assert.EqualError(t, MissingError([]string{"env"}), "missing required argument: env")
+	assert.EqualError(t, MissingError([]string{"env", "count"}), "missing required arguments: env, count")
 }
@@ -20,6 +20,17 @@ type Flag = cli.Flag
 type BoolFlag = cli.BoolFlag
 type StringFlag = cli.StringFlag
 type IntFlag = cli.IntFlag
+type Int64Flag = cli.Int64Flag
+type UintFlag = cli.UintFlag
+type Uint64Flag = cli.Uint64Flag
+type Float64Flag = cli.Float64Flag
+type DurationFlag = cli.DurationFlag
+type StringSliceFlag = cli.StringSliceFlag
+type IntSliceFlag = cli.IntSliceFlag
+type Int64SliceFlag = cli.Int64SliceFlag
+type UintSliceFlag = cli.UintSliceFlag
+type Uint64SliceFlag = cli.Uint64SliceFlag
+type Float64SliceFlag = cli.Float64SliceFlag
 
 // VersionFlag prints the version for the application
 var VersionFlag Flag = &BoolFlag{
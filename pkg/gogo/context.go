@@ -18,6 +18,8 @@ type Context interface {
 	stdContext.Context
 	ShortDescription(short string) Context // This becomes the short description/usage of the command.
 	Example(string) Context                // What would this go to?
+	Alias(...string) Context               // Other names the command can be run with, e.g. `gogo b` for `Build`.
+	Hidden() Context                       // Keeps the command out of the list and help, but it can still be run by name.
 	Argument(any) Argument
 }
 
@@ -25,7 +27,7 @@ type Argument interface {
 	Name(string) Argument             // Override the argument name in auto-complete and arguments when calling this function. Defaults to the name of the argument.
 	Short(byte) Argument              // The short character for the argument
 	Default(any) Argument             // If set it's assumed the argument is also optional
-	Required() Argument               // If set, the required argument is required
+	Required() Argument               // The argument must be given, as a flag or by position, or the function is not run
 	Help(string) Argument             // Help for that specific argument. This is shown when inspecting the individual flag for information, or possibly when auto-completing in shell on positional/flag arguments.
 	AllowedValues(...any) Argument    // Allowed values are checked in the command, and provide options for auto-complete in the shell. For now it's hard-coded values, but in the future could be regular expressions or even a go function.
 	RestrictedValues(...any) Argument // Same as allowed values, but the values are not allowed. This is not used in the shell?
@@ -33,8 +35,19 @@ type Argument interface {
 	Argument(any) Argument            // Start describing a different argument, allows for a builder pattern.
 }
 
+// NewContext returns the context passed to the functions. It is also a context.Context,
+// so it can be passed to functions that take a context.Context, like mage targets.
 func NewContext() Context {
-	return gogoContext{}
+	return WithContext(stdContext.Background())
+}
+
+// WithContext returns the context passed to the functions, which is done when the parent is, like when the
+// generated binary is interrupted. A nil parent is the background context.
+func WithContext(parent stdContext.Context) Context {
+	if parent == nil {
+		parent = stdContext.Background()
+	}
+	return gogoContext{Context: parent}
 }
 
 type gogoContext struct {
@@ -52,6 +65,14 @@ func (c gogoContext) Example(example string) Context {
 	return c
 }
 
+func (c gogoContext) Alias(aliases ...string) Context {
+	return c
+}
+
+func (c gogoContext) Hidden() Context {
+	return c
+}
+
 func (c gogoContext) Argument(arg any) Argument {
 	return &gogoArgument{}
 }
@@ -0,0 +1,234 @@
+package gogo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The formats the value returned by a function can be written in, with the --output flag
+const (
+	OutputText  = "text"
+	OutputJSON  = "json"
+	OutputJSONL = "jsonl"
+	OutputYAML  = "yaml"
+)
+
+var outputFormats = []string{OutputText, OutputJSON, OutputJSONL, OutputYAML}
+
+// OutputFlag selects the format the value returned by a function is written in. It can be given before
+// the function as a global flag, or after it with the function's own flags.
+var OutputFlag Flag = &StringFlag{
+	Name:    "output",
+	Usage:   "the format to write the value returned by the function in: " + strings.Join(outputFormats, ", "),
+	Value:   OutputText,
+	EnvVars: []string{"GOGO_OUTPUT"},
+}
+
+// OutputFormat returns the format selected with the global --output flag, which is given before the function.
+// The function's command isn't looked at, since its flags are the function's own.
+func OutputFormat(ctx *CliContext) (string, error) {
+	lineage := ctx.Lineage()
+	for _, c := range lineage[min(1, len(lineage)):] {
+		if c.IsSet("output") {
+			return c.String("output"), checkOutputFormat(c.String("output"))
+		}
+	}
+	return OutputText, nil
+}
+
+// CutOutputFlag removes the --output flag from the args of a function that returns a value, and returns
+// the format it selects, which defaults to the global --output flag. Args after `--` are left as they are.
+func CutOutputFlag(ctx *CliContext, args []string) ([]string, string, error) {
+	format, err := OutputFormat(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		if value, found := strings.CutPrefix(arg, "--output="); found {
+			format = value
+			continue
+		}
+		if arg == "--output" {
+			if i+1 >= len(args) {
+				return nil, "", fmt.Errorf("flag '--output' needs a format: %s", strings.Join(outputFormats, ", "))
+			}
+			format = args[i+1]
+			i++
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	if err := checkOutputFormat(format); err != nil {
+		return nil, "", err
+	}
+	return rest, format, nil
+}
+
+func checkOutputFormat(format string) error {
+	if !slices.Contains(outputFormats, format) {
+		return fmt.Errorf("unknown output format %q, expected one of: %s", format, strings.Join(outputFormats, ", "))
+	}
+	return nil
+}
+
+// WriteOutput writes the value returned by a function in the format. The text format writes a slice of
+// structs as a table, a struct or map as a line for each field or key, and anything else as it prints.
+// The jsonl format writes each element of a slice as a JSON document on its own line.
+func WriteOutput(w io.Writer, format string, value any) error {
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case OutputJSONL:
+		return writeJSONLines(w, value)
+	case OutputYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(value); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case OutputText, "":
+		return writeText(w, value)
+	}
+	return checkOutputFormat(format)
+}
+
+func writeJSONLines(w io.Writer, value any) error {
+	encoder := json.NewEncoder(w)
+	val := indirect(reflect.ValueOf(value))
+	if !val.IsValid() || (val.Kind() != reflect.Slice && val.Kind() != reflect.Array) || isPrintableType(val.Type()) {
+		return encoder.Encode(value)
+	}
+	for i := 0; i < val.Len(); i++ {
+		if err := encoder.Encode(val.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeText(w io.Writer, value any) error {
+	val := indirect(reflect.ValueOf(value))
+	if !val.IsValid() {
+		return nil
+	}
+	if isPrintable(val) {
+		_, err := fmt.Fprintln(w, val.Interface())
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	switch val.Kind() {
+	case reflect.Slice, reflect.Array:
+		if elem := indirectType(val.Type().Elem()); elem.Kind() == reflect.Struct && !isPrintableType(elem) {
+			writeTable(tw, elem, val)
+			break
+		}
+		for i := 0; i < val.Len(); i++ {
+			fmt.Fprintln(tw, textValue(val.Index(i)))
+		}
+	case reflect.Map:
+		keys := val.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, key := range keys {
+			fmt.Fprintf(tw, "%v:\t%s\n", key.Interface(), textValue(val.MapIndex(key)))
+		}
+	case reflect.Struct:
+		for _, field := range exportedFields(val.Type()) {
+			fmt.Fprintf(tw, "%s:\t%s\n", field.Name, textValue(val.FieldByIndex(field.Index)))
+		}
+	default:
+		fmt.Fprintln(tw, val.Interface())
+	}
+	return tw.Flush()
+}
+
+// writeTable writes the slice of structs as a table, with a column for each exported field
+func writeTable(w io.Writer, elem reflect.Type, rows reflect.Value) {
+	fields := exportedFields(elem)
+	var header []string
+	for _, field := range fields {
+		header = append(header, strings.ToUpper(field.Name))
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for i := 0; i < rows.Len(); i++ {
+		row := indirect(rows.Index(i))
+		cells := make([]string, len(fields))
+		if row.IsValid() {
+			for j, field := range fields {
+				cells[j] = textValue(row.FieldByIndex(field.Index))
+			}
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+}
+
+func exportedFields(typ reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for _, field := range reflect.VisibleFields(typ) {
+		if field.IsExported() && !field.Anonymous {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// textValue formats a field, element or map value, on a single line
+func textValue(val reflect.Value) string {
+	val = indirect(val)
+	if !val.IsValid() {
+		return ""
+	}
+	return strings.ReplaceAll(fmt.Sprint(val.Interface()), "\n", " ")
+}
+
+// isPrintable determines if the value is written as it prints, rather than by its fields or elements
+func isPrintable(val reflect.Value) bool {
+	switch val.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		return isPrintableType(val.Type())
+	}
+	return true
+}
+
+// isPrintableType determines if the type describes how it prints, like a time.Time
+func isPrintableType(typ reflect.Type) bool {
+	stringer := reflect.TypeFor[fmt.Stringer]()
+	errType := reflect.TypeFor[error]()
+	return typ.Implements(stringer) || typ.Implements(errType) ||
+		reflect.PointerTo(typ).Implements(stringer) || typ == reflect.TypeFor[[]byte]()
+}
+
+func indirect(val reflect.Value) reflect.Value {
+	for val.IsValid() && (val.Kind() == reflect.Pointer || val.Kind() == reflect.Interface) {
+		if val.IsNil() {
+			return reflect.Value{}
+		}
+		val = val.Elem()
+	}
+	return val
+}
+
+func indirectType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ
+}
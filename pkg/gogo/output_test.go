@@ -0,0 +1,176 @@
+package gogo
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+type testService struct {
+	Name     string
+	Replicas int
+	Uptime   time.Duration
+	internal string
+}
+
+func TestWriteOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		value    any
+		expected string
+	}{
+		{
+			name:     "text scalar",
+			format:   OutputText,
+			value:    42,
+			expected: "42\n",
+		},
+		{
+			name:     "text slice",
+			format:   OutputText,
+			value:    []string{"api", "worker"},
+			expected: "api\nworker\n",
+		},
+		{
+			name:   "text slice of structs",
+			format: OutputText,
+			value: []testService{
+				{Name: "api", Replicas: 3, Uptime: time.Hour},
+				{Name: "worker", Replicas: 10, Uptime: time.Minute},
+			},
+			expected: "NAME    REPLICAS  UPTIME\napi     3         1h0m0s\nworker  10        1m0s\n",
+		},
+		{
+			name:     "text struct",
+			format:   OutputText,
+			value:    &testService{Name: "api", Replicas: 3},
+			expected: "Name:      api\nReplicas:  3\nUptime:    0s\n",
+		},
+		{
+			name:     "text map",
+			format:   OutputText,
+			value:    map[string]int{"worker": 10, "api": 3},
+			expected: "api:     3\nworker:  10\n",
+		},
+		{
+			name:     "text stringer",
+			format:   OutputText,
+			value:    time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			expected: "2024-03-01 00:00:00 +0000 UTC\n",
+		},
+		{
+			name:     "text nil",
+			format:   OutputText,
+			value:    (*testService)(nil),
+			expected: "",
+		},
+		{
+			name:     "json",
+			format:   OutputJSON,
+			value:    []testService{{Name: "api", Replicas: 3}},
+			expected: "[\n  {\n    \"Name\": \"api\",\n    \"Replicas\": 3,\n    \"Uptime\": 0\n  }\n]\n",
+		},
+		{
+			name:     "jsonl",
+			format:   OutputJSONL,
+			value:    []testService{{Name: "api", Replicas: 3}, {Name: "worker"}},
+			expected: "{\"Name\":\"api\",\"Replicas\":3,\"Uptime\":0}\n{\"Name\":\"worker\",\"Replicas\":0,\"Uptime\":0}\n",
+		},
+		{
+			name:     "yaml",
+			format:   OutputYAML,
+			value:    map[string][]string{"services": {"api", "worker"}},
+			expected: "services:\n  - api\n  - worker\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, WriteOutput(&buf, tt.format, tt.value))
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+
+	assert.ErrorContains(t, WriteOutput(&bytes.Buffer{}, "xml", 1), `unknown output format "xml"`)
+}
+
+func TestCutOutputFlag(t *testing.T) {
+	tests := []struct {
+		name     string
+		global   string
+		args     []string
+		wantArgs []string
+		format   string
+		wantErr  string
+	}{
+		{
+			name:     "default",
+			args:     []string{"--env", "prod"},
+			wantArgs: []string{"--env", "prod"},
+			format:   OutputText,
+		},
+		{
+			name:     "global flag",
+			global:   OutputYAML,
+			args:     []string{"prod"},
+			wantArgs: []string{"prod"},
+			format:   OutputYAML,
+		},
+		{
+			name:     "flag after the function",
+			global:   OutputYAML,
+			args:     []string{"--output", "json", "prod"},
+			wantArgs: []string{"prod"},
+			format:   OutputJSON,
+		},
+		{
+			name:     "flag with equals",
+			args:     []string{"prod", "--output=json"},
+			wantArgs: []string{"prod"},
+			format:   OutputJSON,
+		},
+		{
+			name:     "after --",
+			args:     []string{"prod", "--", "--output", "json"},
+			wantArgs: []string{"prod", "--", "--output", "json"},
+			format:   OutputText,
+		},
+		{
+			name:    "missing format",
+			args:    []string{"--output"},
+			wantErr: "needs a format",
+		},
+		{
+			name:    "unknown format",
+			args:    []string{"--output", "xml"},
+			wantErr: `unknown output format "xml"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			global := flag.NewFlagSet("app", flag.ContinueOnError)
+			global.String("output", OutputText, "")
+			if tt.global != "" {
+				require.NoError(t, global.Parse([]string{"--output", tt.global}))
+			}
+			command := flag.NewFlagSet("command", flag.ContinueOnError)
+			command.String("output", OutputText, "")
+			ctx := cli.NewContext(nil, command, cli.NewContext(nil, global, nil))
+
+			args, format, err := CutOutputFlag(ctx, tt.args)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantArgs, args)
+			assert.Equal(t, tt.format, format)
+		})
+	}
+}
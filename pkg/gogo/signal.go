@@ -0,0 +1,48 @@
+package gogo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// EXIT_INTERRUPTED is the exit code when a second signal stops the functions, like a shell does for Ctrl-C
+const EXIT_INTERRUPTED = 130
+
+// exit is replaced in the tests, which can't exit
+var exit = os.Exit
+
+// SignalContext returns a context that's cancelled on the first SIGINT or SIGTERM, so the functions can stop
+// what they're doing, and the commands started with it, like with exec.CommandContext, are stopped too.
+// A second signal exits right away, for a function that doesn't stop. stop releases the signals.
+func SignalContext() (ctx context.Context, stop context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-done:
+			return
+		}
+		select {
+		case sig := <-signals:
+			_, _ = fmt.Fprintf(os.Stderr, "received %v again, exiting\n", sig)
+			exit(EXIT_INTERRUPTED)
+		case <-done:
+		}
+	}()
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+			cancel()
+		})
+	}
+}
@@ -0,0 +1,63 @@
+//go:build unix
+
+package gogo
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignalContext(t *testing.T) {
+	exited := make(chan int, 1)
+	exit = func(code int) { exited <- code }
+	defer func() { exit = os.Exit }()
+
+	ctx, stop := SignalContext()
+	defer stop()
+	require.NoError(t, ctx.Err())
+
+	// the first signal cancels the context
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGINT))
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the context wasn't cancelled by the signal")
+	}
+	assert.Empty(t, exited)
+
+	// and the second one exits
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+	select {
+	case code := <-exited:
+		assert.Equal(t, EXIT_INTERRUPTED, code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the second signal didn't exit")
+	}
+}
+
+func TestSignalContextStop(t *testing.T) {
+	ctx, stop := SignalContext()
+	stop()
+	stop()
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+}
+
+func TestWithContext(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	ctx := WithContext(parent)
+	require.NoError(t, ctx.Err())
+	cancel()
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+
+	// the context of a function is never nil, so it can be passed to anything that takes a context.Context
+	assert.NotPanics(t, func() {
+		_ = WithContext(nil).Done()
+		_ = NewContext().Err()
+	})
+}
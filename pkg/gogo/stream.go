@@ -0,0 +1,164 @@
+package gogo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WriteSeq writes each value of the sequence as it's produced, in the format of the --output flag.
+// It stops when the sequence ends, or when the context is cancelled.
+func WriteSeq[T any](ctx context.Context, w io.Writer, format string, seq iter.Seq[T]) error {
+	stream, err := newStreamWriter(w, format)
+	if err != nil {
+		return err
+	}
+	for value := range seq {
+		if err := stream.write(value); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return stream.close()
+}
+
+// WriteSeq2 writes each value of the sequence as it's produced, like WriteSeq, and returns the first error
+// the sequence produces.
+func WriteSeq2[T any](ctx context.Context, w io.Writer, format string, seq iter.Seq2[T, error]) error {
+	stream, err := newStreamWriter(w, format)
+	if err != nil {
+		return err
+	}
+	for value, err := range seq {
+		if err != nil {
+			// close what's been written so far, so a json array is still complete
+			_ = stream.close()
+			return err
+		}
+		if err := stream.write(value); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return stream.close()
+}
+
+// WriteChan writes each value received from the channel as it arrives, like WriteSeq.
+// It stops when the channel is closed, or when the context is cancelled.
+func WriteChan[T any](ctx context.Context, w io.Writer, format string, values <-chan T) error {
+	stream, err := newStreamWriter(w, format)
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return stream.close()
+		case value, ok := <-values:
+			if !ok {
+				return stream.close()
+			}
+			if err := stream.write(value); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// streamWriter writes values one at a time, without waiting for the rest of them. Since later values
+// aren't known yet, text columns aren't aligned, and json is written as an array as the values arrive.
+type streamWriter struct {
+	w       io.Writer
+	format  string
+	count   int
+	columns []reflect.StructField // the columns of the text table, once the header is written
+	yaml    *yaml.Encoder
+}
+
+func newStreamWriter(w io.Writer, format string) (*streamWriter, error) {
+	if format == "" {
+		format = OutputText
+	}
+	if err := checkOutputFormat(format); err != nil {
+		return nil, err
+	}
+	stream := &streamWriter{w: w, format: format}
+	if format == OutputYAML {
+		stream.yaml = yaml.NewEncoder(w)
+		stream.yaml.SetIndent(2)
+	}
+	return stream, nil
+}
+
+func (s *streamWriter) write(value any) error {
+	defer func() { s.count++ }()
+	switch s.format {
+	case OutputJSON:
+		data, err := json.MarshalIndent(value, "  ", "  ")
+		if err != nil {
+			return err
+		}
+		separator := ",\n  "
+		if s.count == 0 {
+			separator = "[\n  "
+		}
+		_, err = fmt.Fprintf(s.w, "%s%s", separator, data)
+		return err
+	case OutputJSONL:
+		return json.NewEncoder(s.w).Encode(value)
+	case OutputYAML:
+		return s.yaml.Encode(value)
+	}
+	return s.writeText(value)
+}
+
+// writeText writes a struct as a row of a table, with the header before the first row,
+// and anything else the way WriteOutput does.
+func (s *streamWriter) writeText(value any) error {
+	val := indirect(reflect.ValueOf(value))
+	if !val.IsValid() || val.Kind() != reflect.Struct || isPrintableType(val.Type()) {
+		return writeText(s.w, value)
+	}
+	if s.columns == nil {
+		s.columns = exportedFields(val.Type())
+		var header []string
+		for _, field := range s.columns {
+			header = append(header, strings.ToUpper(field.Name))
+		}
+		if _, err := fmt.Fprintln(s.w, strings.Join(header, "\t")); err != nil {
+			return err
+		}
+	}
+	cells := make([]string, len(s.columns))
+	for i, field := range s.columns {
+		cells[i] = textValue(val.FieldByIndex(field.Index))
+	}
+	_, err := fmt.Fprintln(s.w, strings.Join(cells, "\t"))
+	return err
+}
+
+// close finishes the output once all the values are written
+func (s *streamWriter) close() error {
+	switch s.format {
+	case OutputJSON:
+		if s.count == 0 {
+			_, err := fmt.Fprintln(s.w, "[]")
+			return err
+		}
+		_, err := fmt.Fprint(s.w, "\n]\n")
+		return err
+	case OutputYAML:
+		return s.yaml.Close()
+	}
+	return nil
+}
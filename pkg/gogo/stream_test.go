@@ -0,0 +1,113 @@
+package gogo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"iter"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSeq(t *testing.T) {
+	services := []testService{{Name: "api", Replicas: 3}, {Name: "worker", Replicas: 10}}
+	tests := []struct {
+		name     string
+		format   string
+		values   []testService
+		expected string
+	}{
+		{
+			name:     "text",
+			format:   OutputText,
+			values:   services,
+			expected: "NAME\tREPLICAS\tUPTIME\napi\t3\t0s\nworker\t10\t0s\n",
+		},
+		{
+			name:     "json",
+			format:   OutputJSON,
+			values:   services[:1],
+			expected: "[\n  {\n    \"Name\": \"api\",\n    \"Replicas\": 3,\n    \"Uptime\": 0\n  }\n]\n",
+		},
+		{
+			name:     "json without values",
+			format:   OutputJSON,
+			expected: "[]\n",
+		},
+		{
+			name:     "jsonl",
+			format:   OutputJSONL,
+			values:   services,
+			expected: "{\"Name\":\"api\",\"Replicas\":3,\"Uptime\":0}\n{\"Name\":\"worker\",\"Replicas\":10,\"Uptime\":0}\n",
+		},
+		{
+			name:     "yaml",
+			format:   OutputYAML,
+			values:   services,
+			expected: "name: api\nreplicas: 3\nuptime: 0s\n---\nname: worker\nreplicas: 10\nuptime: 0s\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, WriteSeq(context.Background(), &buf, tt.format, slices.Values(tt.values)))
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+
+	err := WriteSeq(context.Background(), &bytes.Buffer{}, "xml", slices.Values(services))
+	assert.ErrorContains(t, err, `unknown output format "xml"`)
+}
+
+func TestWriteSeqCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var produced int
+	seq := func(yield func(int) bool) {
+		for i := 1; ; i++ {
+			produced = i
+			if i == 2 {
+				cancel()
+			}
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, WriteSeq(ctx, &buf, OutputJSONL, iter.Seq[int](seq)))
+	assert.Equal(t, "1\n2\n", buf.String())
+	assert.Equal(t, 2, produced)
+}
+
+func TestWriteSeq2(t *testing.T) {
+	seq := func(yield func(string, error) bool) {
+		if !yield("api", nil) {
+			return
+		}
+		yield("", errors.New("connection lost"))
+	}
+	var buf bytes.Buffer
+	err := WriteSeq2(context.Background(), &buf, OutputJSON, iter.Seq2[string, error](seq))
+	assert.EqualError(t, err, "connection lost")
+	assert.Equal(t, "[\n  \"api\"\n]\n", buf.String())
+}
+
+func TestWriteChan(t *testing.T) {
+	values := make(chan string, 2)
+	values <- "api"
+	values <- "worker"
+	close(values)
+	var buf bytes.Buffer
+	require.NoError(t, WriteChan(context.Background(), &buf, OutputText, values))
+	assert.Equal(t, "api\nworker\n", buf.String())
+
+	// a cancelled context stops waiting for values that never arrive
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	buf.Reset()
+	require.NoError(t, WriteChan(ctx, &buf, OutputJSON, make(chan string)))
+	assert.Equal(t, "[]\n", buf.String())
+}
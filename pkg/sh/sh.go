@@ -14,7 +14,9 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/mvdan/sh/shell"
@@ -30,6 +32,8 @@ type Executor struct {
 	stdOut            io.Writer
 	stdErr            io.Writer
 	stdIn             io.Reader
+	caught            []os.Signal // the signals that don't stop this process while the command runs
+	forwarded         []os.Signal // the signals that are passed on to the command while it runs
 }
 
 func Cmd(input ...string) *Executor {
@@ -98,6 +102,20 @@ func (e *Executor) AddEnv(env []string) *Executor {
 	return e
 }
 
+// CatchSignals keeps the signals from stopping this process while the command runs, so it waits for the command
+// to stop, like a shell does. Use it for the signals a terminal sends to the command as well, like Ctrl-C.
+func (e *Executor) CatchSignals(signals ...os.Signal) *Executor {
+	e.caught = append(e.caught, signals...)
+	return e
+}
+
+// ForwardSignals passes the signals this process receives on to the command while it runs, instead of this
+// process stopping and leaving the command running
+func (e *Executor) ForwardSignals(signals ...os.Signal) *Executor {
+	e.forwarded = append(e.forwarded, signals...)
+	return e
+}
+
 // StdOut runs the command, and returns the stdout as a string
 func (e *Executor) StdOut() (string, error) {
 	var out bytes.Buffer
@@ -179,6 +197,40 @@ func (e *Executor) Run() error {
 	c.Stderr = e.stdErr
 	c.Stdin = e.stdIn
 
-	err := c.Run()
+	if len(e.caught) == 0 && len(e.forwarded) == 0 {
+		return c.Run()
+	}
+	return e.runWithSignals(c)
+}
+
+// runWithSignals runs the command, catching and forwarding the signals received until it exits
+func (e *Executor) runWithSignals(c *exec.Cmd) error {
+	signals := append(slices.Clone(e.caught), e.forwarded...)
+	received := make(chan os.Signal, len(signals))
+	signal.Notify(received, signals...)
+	defer signal.Stop(received)
+	if err := c.Start(); err != nil {
+		return err
+	}
+	// the process is read once here, so the goroutine doesn't touch the command while Wait uses it
+	process := c.Process
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case sig := <-received:
+				if slices.Contains(e.forwarded, sig) {
+					_ = process.Signal(sig)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	err := c.Wait()
+	close(done)
+	<-stopped
 	return err
 }
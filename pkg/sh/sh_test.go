@@ -3,11 +3,16 @@ package sh
 import (
 	"bytes"
 	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -177,6 +182,40 @@ func (s *ShTestSuite) TestCmdWithCtx_Cancellation() {
 	assert.Error(s.T(), err)
 }
 
+// --- Signals ---
+
+func (s *ShTestSuite) TestForwardSignals() {
+	ready := filepath.Join(s.T().TempDir(), "ready")
+	go func() {
+		// the signal is only sent once the command has set up its trap, so it's the command that receives it
+		for {
+			if _, err := os.Stat(ready); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		_ = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	}()
+	// the command is stopped by the signal sent to this process, which keeps running
+	script := "trap 'echo stopped; exit 3' TERM; touch '" + ready + "'; sleep 5 >/dev/null & wait"
+	out, err := Cmd("sh", "-c", script).ForwardSignals(syscall.SIGTERM).StdOut()
+	var exitErr *exec.ExitError
+	require.ErrorAs(s.T(), err, &exitErr)
+	assert.Equal(s.T(), 3, exitErr.ExitCode())
+	assert.Equal(s.T(), "stopped\n", out)
+}
+
+func (s *ShTestSuite) TestCatchSignals() {
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = syscall.Kill(os.Getpid(), syscall.SIGINT)
+	}()
+	// the signal isn't passed on, so the command finishes
+	out, err := Cmd("sh", "-c", "sleep 0.5; echo finished").CatchSignals(os.Interrupt).StdOut()
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "finished\n", out)
+}
+
 // --- DetermineWidth (T008) ---
 
 func (s *ShTestSuite) TestDetermineWidth_NotTerminal() {
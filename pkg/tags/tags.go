@@ -23,18 +23,9 @@ func HasBuildTag(src string, tags []string) bool {
 	lines := strings.Split(src, "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "//go:build") {
-			tagLine := strings.TrimSpace(line[len("//go:build"):])
-			tagExpr := strings.ReplaceAll(tagLine, " ", ",") // Convert space to comma for evaluation
-			if matchesBuildTag(tagExpr, tags) {
-				return true
-			}
-		}
-		// Check for // +build (old format)
-		if strings.HasPrefix(line, "// +build") {
-			tagLine := strings.TrimSpace(line[len("// +build"):])
-			tagExpr := strings.ReplaceAll(tagLine, " ", ",") // Convert space to comma for evaluation
-			if matchesBuildTag(tagExpr, tags) {
+		// the constraint parser understands both the //go:build and the old // +build format
+		if constraint.IsGoBuild(line) || constraint.IsPlusBuild(line) {
+			if matchesBuildTag(line, tags) {
 				return true
 			}
 		}
@@ -42,15 +33,19 @@ func HasBuildTag(src string, tags []string) bool {
 	return false
 }
 
-// matchesBuildTag parses the build expression and checks the given tags.
-func matchesBuildTag(buildExpr string, tags []string) bool {
-	expr, err := constraint.Parse(buildExpr) // Parse the build expression using go/build/constraint
+// matchesBuildTag parses the build constraint line and checks the given tags.
+func matchesBuildTag(buildLine string, tags []string) bool {
+	expr, err := constraint.Parse(buildLine) // Parse the build constraint using go/build/constraint
 	if err != nil {
 		fmt.Println("Error parsing build expression:", err)
 		return false
 	}
 
-	// Loop through the provided tags and check if any match the constraint expression.
+	// A constraint that holds without the tag, like !windows, isn't a file for the tag, so a tag only
+	// matches when the constraint holds with it set, and doesn't without it.
+	if expr.Eval(func(string) bool { return false }) {
+		return false
+	}
 	for _, tag := range tags {
 		if expr.Eval(func(name string) bool { return name == tag }) {
 			return true
@@ -0,0 +1,34 @@
+package tags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasBuildTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		tags     []string
+		expected bool
+	}{
+		{name: "go build tag", src: "//go:build gogo\n\npackage main", tags: []string{"gogo"}, expected: true},
+		{name: "one of many tags", src: "//go:build mage\n\npackage main", tags: []string{"gogo", "mage"}, expected: true},
+		{name: "plus build tag", src: "// +build mage\n\npackage main", tags: []string{"mage"}, expected: true},
+		{name: "expression", src: "//go:build gogo || mage\n\npackage main", tags: []string{"mage"}, expected: true},
+		{name: "different tag", src: "//go:build tools\n\npackage main", tags: []string{"gogo"}, expected: false},
+		{name: "negated tag", src: "//go:build !gogo\n\npackage main", tags: []string{"gogo"}, expected: false},
+		{name: "negated other tag", src: "//go:build !windows\n\npackage main", tags: []string{"gogo"}, expected: false},
+		{name: "negated other tag in plus build", src: "// +build !windows\n\npackage main", tags: []string{"gogo", "mage"}, expected: false},
+		{name: "tag or negated tag", src: "//go:build gogo || !windows\n\npackage main", tags: []string{"gogo"}, expected: false},
+		{name: "tag and negated tag", src: "//go:build gogo && !windows\n\npackage main", tags: []string{"gogo"}, expected: true},
+		{name: "no tag", src: "package main", tags: []string{"gogo"}, expected: false},
+		{name: "no tags requested", src: "package main", tags: nil, expected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, HasBuildTag(tt.src, tt.tags))
+		})
+	}
+}
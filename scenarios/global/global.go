@@ -0,0 +1,25 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package main
+
+import (
+	"fmt"
+)
+
+// This folder stands in for a global namespace (~/.gogo), so the functions live
+// at the root of the folder instead of inside a .gogo folder.
+
+// GlobalOnly only exists in the global namespace.
+func GlobalOnly() {
+	fmt.Println("GlobalOnly")
+}
+
+// SingleArgument collides with the function of the same name in the standard scenario.
+func SingleArgument(arg1 string) {
+	fmt.Printf("Global SingleArgument with arg1: %v\n", arg1)
+}
@@ -0,0 +1,21 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package compose
+
+import (
+	"fmt"
+
+	"github.com/2bit-software/gogo/pkg/gogo"
+)
+
+// Up starts the services
+func Up(ctx gogo.Context) error {
+	ctx.Alias("up")
+	fmt.Println("compose up")
+	return nil
+}
@@ -0,0 +1,27 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package docker
+
+import (
+	"fmt"
+
+	"github.com/2bit-software/gogo/scenarios/grouped/gadgets/internal"
+)
+
+// Build builds the docker image with the given tag
+func Build(tag string) {
+	fmt.Println(internal.Describe("docker build", tag))
+}
+
+// Image manages the docker images
+type Image struct{}
+
+// Push pushes the image with the given tag
+func (Image) Push(tag string) {
+	fmt.Println(internal.Describe("docker push", tag))
+}
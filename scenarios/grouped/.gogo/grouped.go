@@ -0,0 +1,52 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/2bit-software/gogo/pkg/gogo"
+)
+
+// Hello is not in a command group
+func Hello(ctx gogo.Context) {
+	ctx.Alias("hi")
+	fmt.Println("Hello from the root")
+}
+
+// Docker has the name of the docker group, and is run when none of the group's commands are
+func Docker() {
+	fmt.Println("docker")
+}
+
+// Release publishes the binaries. Its methods are the release commands.
+type Release struct {
+	published bool
+}
+
+// Notes prints the notes of the release
+func (Release) Notes(version string) {
+	fmt.Printf("notes for %s\n", version)
+}
+
+// Publish publishes the binaries, on a new Release each time it's run
+func (r *Release) Publish() error {
+	if r.published {
+		return fmt.Errorf("already published")
+	}
+	r.published = true
+	fmt.Println("published")
+	return nil
+}
+
+// Cleanup removes the build artifacts, and is only run by the other functions
+//
+//gogo:hidden
+func Cleanup() {
+	fmt.Println("cleaned up")
+}
@@ -0,0 +1,17 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package internal
+
+import (
+	"fmt"
+)
+
+// Describe is shared by the command groups, and is not a gadget itself
+func Describe(action, target string) string {
+	return fmt.Sprintf("%s: %s", action, target)
+}
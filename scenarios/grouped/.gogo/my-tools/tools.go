@@ -0,0 +1,16 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+// Package tools is in a folder with a dash in its name, which isn't a valid go identifier
+package tools
+
+import "fmt"
+
+// Lint lints the tools
+func Lint() {
+	fmt.Println("linted the tools")
+}
@@ -0,0 +1,54 @@
+//go:build mage
+
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/magefile/mage/mg"
+)
+
+// Default is run when no target is given
+var Default = Build
+
+var Aliases = map[string]interface{}{
+	"b":  Build,
+	"up": Docker.Up,
+}
+
+type Docker mg.Namespace
+
+// Generate runs once, no matter how many targets depend on it
+func Generate() {
+	fmt.Println("generate")
+}
+
+// Build depends on Generate
+func Build() error {
+	mg.Deps(Generate, Generate)
+	fmt.Println("build")
+	return nil
+}
+
+// Up starts the containers
+func (Docker) Up(ctx context.Context, detach bool) error {
+	mg.CtxDeps(ctx, Generate)
+	fmt.Printf("docker up detach=%v\n", detach)
+	return nil
+}
+
+// helper is not a target, since mage only runs exported functions
+func (Docker) helper() {}
+
+type notANamespace struct{}
+
+// Method is not a target, since the type is not a mage namespace
+func (notANamespace) Method() {}
@@ -23,7 +23,7 @@ func NoArgumentsNoReturns() {
 }
 
 // DescriptionOnly This is the description for the function. Without any other arguments to the ctx,
-// this will show up in the list view and the --help output.
+// its first sentence will show up in the list view, and all of it in the --help output.
 func DescriptionOnly() {
 	fmt.Println("DescriptionOnly")
 }
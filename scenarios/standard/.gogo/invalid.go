@@ -12,9 +12,9 @@ func GoGoCtxInWrongPos(var1 string, ctx gogo.Context, var2 bool) error {
 	return nil
 }
 
-// not valid because we can only return an error or nothing
-func WrongReturnType(ctx gogo.Context, var1 string, var2 bool) string {
-	return ""
+// not valid because we can only return a value and an error, in that order
+func WrongReturnType(ctx gogo.Context, var1 string, var2 bool) (error, string) {
+	return nil, ""
 }
 
 type MyType struct{}
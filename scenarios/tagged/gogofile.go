@@ -0,0 +1,21 @@
+//go:build gogo
+
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/2bit-software/gogo/pkg/funcs/scenarios/tagged/greeting"
+)
+
+// GoGoTagged lives next to the project's own go files, and is found by its build tag.
+func GoGoTagged(name string) {
+	fmt.Println(greeting.Greeting(name))
+}
@@ -0,0 +1,14 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+// Package greeting is a package of the project, which the tagged gadgets import
+package greeting
+
+// Greeting greets the name
+func Greeting(name string) string {
+	return "GoGoTagged with name: " + name
+}
@@ -0,0 +1,26 @@
+//go:build mage
+
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+// the tagged files are built in place, so embedded files are found next to them
+//
+//go:embed VERSION
+var version string
+
+// MageTagged lives next to the project's own go files, and is found by its build tag.
+func MageTagged() {
+	fmt.Println("MageTagged", strings.TrimSpace(version))
+}
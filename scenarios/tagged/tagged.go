@@ -0,0 +1,13 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+// Package tagged is a regular package, that shares its folder with tagged gadget files.
+// It must not be included in the gadget binary.
+package tagged
+
+// NotAGadget should never show up as a gadget.
+func NotAGadget() {}
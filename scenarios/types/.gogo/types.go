@@ -0,0 +1,184 @@
+// Copyright (C) 2024  Morgan S Hein
+//
+// This program is subject to the terms
+// of the GNU Affero General Public License, version 3.
+// If a copy of the AGPL was not distributed with this file, You
+// can obtain one at https://www.gnu.org/licenses/.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/2bit-software/gogo/pkg/gogo"
+)
+
+// Deploy deploys the services, waiting up to the timeout for them to start
+func Deploy(services []string, timeout time.Duration, replicas uint) {
+	fmt.Printf("deploy %s timeout=%s replicas=%d\n", strings.Join(services, "+"), timeout, replicas)
+}
+
+// Since shows the changes since the given date
+func Since(since time.Time) {
+	fmt.Printf("since %s\n", since.Format(time.DateOnly))
+}
+
+// Scale sets the number of replicas, and the traffic weight of each
+func Scale(ctx gogo.Context, replicas int64, weights []float64) {
+	ctx.Argument(replicas).
+		AllowedValues(1, 2, 3)
+	fmt.Printf("scale replicas=%d weights=%v\n", replicas, weights)
+}
+
+// Env is the environment to run against
+type Env string
+
+// UnmarshalText only accepts the known environments
+func (e *Env) UnmarshalText(text []byte) error {
+	switch env := Env(text); env {
+	case "dev", "staging", "prod":
+		*e = env
+		return nil
+	}
+	return fmt.Errorf("unknown environment %q", text)
+}
+
+// The environments that can be run against
+const (
+	EnvDev     Env = "dev"
+	EnvStaging Env = "staging"
+	EnvProd    Env = "prod"
+)
+
+// MaxBatch is the most services rolled out at once
+const MaxBatch = 2 * 5
+
+var defaultWait = time.Second * 30
+
+// Rollout rolls the services out in batches, waiting between each
+func Rollout(ctx gogo.Context, env Env, batch int, wait time.Duration) {
+	ctx.Argument(env).AllowedValues(EnvDev, EnvStaging)
+	ctx.Argument(batch).AllowedValues(1, MaxBatch/2, MaxBatch)
+	ctx.Argument(wait).Default(defaultWait)
+	fmt.Printf("rollout env=%s batch=%d wait=%s\n", env, batch, wait)
+}
+
+// Ping checks that the endpoint of the environment is reachable from the address
+func Ping(addr netip.Addr, env Env, endpoint url.URL) {
+	fmt.Printf("ping %s env=%s host=%s\n", addr, env, endpoint.Host)
+}
+
+// ReleaseOpts are the options of a release
+type ReleaseOpts struct {
+	// Version is the version to release
+	Version string `short:"v" required:"true"`
+	Env     Env    `default:"staging"`
+	// DryRun only shows what would be released
+	DryRun  bool
+	Targets []string      `long:"target" help:"the targets to build"`
+	Timeout time.Duration `default:"5m" env:"RELEASE_TIMEOUT"`
+	// Mirror is the address of the mirror to publish to
+	Mirror netip.Addr
+	notes  string
+}
+
+// Release releases a version to the environment
+func Release(ctx gogo.Context, o ReleaseOpts) error {
+	fmt.Printf("release %s env=%s dry-run=%t targets=%s timeout=%s mirror=%s\n",
+		o.Version, o.Env, o.DryRun, strings.Join(o.Targets, "+"), o.Timeout, o.Mirror)
+	return nil
+}
+
+// Exec shows the command that would be run with the arguments
+func Exec(cmd string, dryRun bool, args ...string) {
+	fmt.Printf("exec %s %s dry-run=%t\n", cmd, strings.Join(args, " "), dryRun)
+}
+
+// Sum adds the numbers
+func Sum(numbers ...int) {
+	total := 0
+	for _, n := range numbers {
+		total += n
+	}
+	fmt.Printf("sum %d\n", total)
+}
+
+// Service is a service running in an environment
+type Service struct {
+	Name     string `json:"name" yaml:"name"`
+	Replicas int    `json:"replicas" yaml:"replicas"`
+}
+
+// Services lists the services running in the environment
+func Services(env Env) ([]Service, error) {
+	if env == "prod" {
+		return nil, fmt.Errorf("listing the services in prod is not allowed")
+	}
+	return []Service{{Name: "api", Replicas: 3}, {Name: "worker", Replicas: 10}}, nil
+}
+
+// Version returns the version that is deployed
+func Version() string {
+	return "1.2.0"
+}
+
+// Tail streams the last lines of the log, failing when it reaches a line it can't read
+func Tail(lines int) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		for i := 1; i <= lines; i++ {
+			if i == 3 {
+				yield("", fmt.Errorf("line %d is corrupted", i))
+				return
+			}
+			if !yield(fmt.Sprintf("line %d", i), nil) {
+				return
+			}
+		}
+	}
+}
+
+// Watch streams the services as they start
+func Watch(ctx context.Context) <-chan Service {
+	services := make(chan Service)
+	go func() {
+		defer close(services)
+		for _, service := range []Service{{Name: "api", Replicas: 3}, {Name: "worker", Replicas: 10}} {
+			select {
+			case services <- service:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return services
+}
+
+// Level is how much is logged
+type Level string
+
+const (
+	// LevelQuiet only logs errors
+	LevelQuiet Level = "quiet"
+	// LevelInfo logs what's done
+	LevelInfo  Level = "info"
+	LevelDebug Level = "debug" // logs everything
+)
+
+// Log logs the message at the level
+func Log(level Level, message string) {
+	fmt.Printf("log level=%s message=%s\n", level, message)
+}
+
+// Copy copies the files to the host, on the port, which has to be given even when it's 0
+func Copy(ctx gogo.Context, host string, port int, files ...string) {
+	ctx.Argument(host).Required()
+	ctx.Argument(port).Required()
+	ctx.Argument(files).Required()
+	fmt.Printf("copy %s to %s:%d\n", strings.Join(files, "+"), host, port)
+}